@@ -1,11 +1,9 @@
 package main
 
 import (
-	"bufio"
-	"os"
-	"strconv"
-
 	"github.com/kubil6y/go_game_engine/pkg/asset_store"
+	"github.com/kubil6y/go_game_engine/pkg/ecs"
+	"github.com/kubil6y/go_game_engine/pkg/engine"
 	"github.com/kubil6y/go_game_engine/pkg/vector"
 )
 
@@ -15,6 +13,13 @@ const (
 	IMG_Tilemap
 )
 
+// assetNames are the names prefabs and JSON snapshots use for assets.
+var assetNames = map[string]asset_store.AssetID{
+	"chopper": IMG_Chopper,
+	"tank":    IMG_Tank,
+	"tilemap": IMG_Tilemap,
+}
+
 const (
 	tileSize   = 32
 	tileScale  = 2.0
@@ -23,58 +28,37 @@ const (
 )
 
 func (g *Game) LoadAssets() error {
-	g.assetStore.AddTexture(g.renderer, IMG_Tilemap, "./assets/tilemaps/jungle.png")
-	g.assetStore.AddTexture(g.renderer, IMG_Tank, "./assets/images/tank-panther-left.png")
-	g.assetStore.AddTexture(g.renderer, IMG_Chopper, "./assets/images/chopper-spritesheet.png")
+	g.loadTextures()
+
+	if err := g.registry.LoadPrefabFile(g.app.AssetPath("prefabs/tanks.json")); err != nil {
+		g.app.Logger().Error(err, "failed to load prefabs", nil)
+		return err
+	}
 
 	// render the map
-	mapFile, err := os.Open("./assets/tilemaps/jungle.map")
+	tiles, err := engine.LoadTilemap(g.app.AssetPath("tilemaps/jungle.map"), mapNumCols, mapNumRows)
 	if err != nil {
-		g.logger.Fatal(err, "failed to read map file", nil)
+		g.app.Logger().Error(err, "failed to read map file", nil)
+		return err
 	}
-	defer mapFile.Close()
-
-	reader := bufio.NewReader(mapFile)
-	for y := 0; y < mapNumRows; y++ {
-		for x := 0; x < mapNumCols; x++ {
-			// Read first character
-			ch, err := reader.ReadByte()
-			if err != nil {
-				g.logger.Fatal(err, "Error reading map file", nil)
-				return err
-			}
-			srcRectY, _ := strconv.Atoi(string(ch))
-			srcRectY *= tileSize
-
-			ch, err = reader.ReadByte()
-			if err != nil {
-				g.logger.Fatal(err, "Error reading map file", nil)
-				return err
-			}
-			srcRectX, _ := strconv.Atoi(string(ch))
-			srcRectX *= tileSize
-
-			reader.Discard(1)
-
-			tile := g.registry.CreateEntity()
-			g.registry.AddComponent(tile,
-				TRANSFORM_COMPONENT,
-				TransformComponent{
-					Position: vector.Vec2{
-						X: float32(x) * (tileScale * tileSize),
-						Y: float32(y) * (tileScale * tileSize),
-					},
-					Scale:    vector.Vec2{X: tileScale, Y: tileScale},
-					Rotation: 0.0,
-				},
-			)
+	for _, t := range tiles {
+		tile := g.registry.CreateEntity()
+		ecs.Add(g.registry, tile, TransformComponent{
+			Position: vector.Vec2{
+				X: float32(t.Col) * (tileScale * tileSize),
+				Y: float32(t.Row) * (tileScale * tileSize),
+			},
+			Scale:    vector.Vec2{X: tileScale, Y: tileScale},
+			Rotation: 0.0,
+		})
 
-			g.registry.AddComponent(tile, SPRITE_COMPONENT, NewSpriteComponent(IMG_Tilemap, tileSize, tileSize, 0, false, srcRectX, srcRectY))
-		}
+		ecs.Add(g.registry, tile, NewSpriteComponent(IMG_Tilemap, tileSize, tileSize, 0, false, t.SrcCol*tileSize, t.SrcRow*tileSize))
 	}
 
-	g.mapWidth = mapNumCols * tileSize * tileScale
-	g.mapHeight = mapNumRows * tileSize * tileScale
+	ecs.SetResource(g.registry, engine.MapBounds{
+		Width:  mapNumCols * tileSize * tileScale,
+		Height: mapNumRows * tileSize * tileScale,
+	})
 
 	return nil
 }
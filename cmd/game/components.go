@@ -1,36 +1,49 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/kubil6y/go_game_engine/pkg/asset_store"
 	"github.com/kubil6y/go_game_engine/pkg/ecs"
+	"github.com/kubil6y/go_game_engine/pkg/engine"
 	"github.com/kubil6y/go_game_engine/pkg/vector"
-	"github.com/veandco/go-sdl2/sdl"
-)
-
-const (
-	SPRITE_COMPONENT ecs.ComponentTypeID = iota
-	BOX_COLLIDER_COMPONENT
-	TRANSFORM_COMPONENT
-	RIGIDBODY_COMPONENT
-	ANIMATION_COMPONENT
-	KEYBOARD_CONTROLLED_COMPONENT
-	CAMERA_FOLLOW_COMPONENT
-    TANK_SPAWNER_COMPONENT
 )
 
 const (
 	MAX_COMPONENTS_AMOUNT = 32
 )
 
+// RegisterComponentCodecs names the components that prefabs and snapshots
+// can hold.
+func RegisterComponentCodecs(registry *ecs.Registry) error {
+	return errors.Join(
+		ecs.RegisterCodec[SpriteComponent](registry, "sprite"),
+		ecs.RegisterCodec[TransformComponent](registry, "transform"),
+		ecs.RegisterCodec[LocalTransformComponent](registry, "local_transform"),
+		ecs.RegisterCodec[BoxColliderComponent](registry, "box_collider"),
+		ecs.RegisterCodec[RigidbodyComponent](registry, "rigidbody"),
+		ecs.RegisterCodec[AnimationComponent](registry, "animation"),
+		ecs.RegisterCodec[KeyboardControlledComponent](registry, "keyboard_controlled"),
+		ecs.RegisterCodec[CameraFollowComponent](registry, "camera_follow"),
+		ecs.RegisterCodec[TankSpawnerComponent](registry, "tank_spawner"),
+	)
+}
+
 ///////////////////////////////////////////////////
+// SpriteComponent names its asset in JSON, see assetNames:
+//
+//	{"Asset": "tank", "Width": 32, "Height": 32}
 type SpriteComponent struct {
 	Name    string
-	AssetID asset_store.AssetID
+	AssetID asset_store.AssetID `json:"-"`
 	Width   int
 	Height  int
 	ZIndex  int
 	IsFixed bool
-	SrcRect sdl.Rect
+	SrcRect engine.Rect
 }
 
 func NewSpriteComponent(assetID asset_store.AssetID, width, height, zIndex int, isFixed bool, srcRectX, srcRectY int) SpriteComponent {
@@ -41,7 +54,7 @@ func NewSpriteComponent(assetID asset_store.AssetID, width, height, zIndex int,
 		Height:  height,
 		ZIndex:  zIndex,
 		IsFixed: isFixed,
-		SrcRect: sdl.Rect{
+		SrcRect: engine.Rect{
 			X: int32(srcRectX),
 			Y: int32(srcRectY),
 			W: int32(width),
@@ -50,29 +63,76 @@ func NewSpriteComponent(assetID asset_store.AssetID, width, height, zIndex int,
 	}
 }
 
-func (c SpriteComponent) GetID() int {
-	return int(SPRITE_COMPONENT)
-}
-
 func (c SpriteComponent) String() string {
 	return "SpriteComponent"
 }
 
+func (c SpriteComponent) MarshalJSON() ([]byte, error) {
+	type sprite SpriteComponent
+	for name, assetID := range assetNames {
+		if assetID == c.AssetID {
+			return json.Marshal(struct {
+				Asset string
+				sprite
+			}{name, sprite(c)})
+		}
+	}
+	return nil, fmt.Errorf("sprite: asset %d has no name", c.AssetID)
+}
+
+// UnmarshalJSON keeps the asset of c when the data doesn't name one.
+func (c *SpriteComponent) UnmarshalJSON(data []byte) error {
+	type sprite SpriteComponent
+	v := struct {
+		Asset string
+		*sprite
+	}{sprite: (*sprite)(c)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Asset == "" {
+		return nil
+	}
+	assetID, exists := assetNames[v.Asset]
+	if !exists {
+		return fmt.Errorf("sprite: unknown asset %q", v.Asset)
+	}
+	c.AssetID = assetID
+	return nil
+}
+
 ///////////////////////////////////////////////////
 type TransformComponent struct {
 	Position vector.Vec2
 	Scale    vector.Vec2
 	Rotation float32
-}
-
-func (c TransformComponent) GetID() int {
-	return int(TRANSFORM_COMPONENT)
+	// Position before the last simulation step, see TransformHistorySystem
+	PreviousPosition vector.Vec2 `json:"-"`
 }
 
 func (c TransformComponent) String() string {
 	return "TransformComponent"
 }
 
+// Interpolated returns the position to render at alpha between the last two
+// simulation steps.
+func (c TransformComponent) Interpolated(alpha float32) vector.Vec2 {
+	return vector.Lerp(c.PreviousPosition, c.Position, alpha)
+}
+
+///////////////////////////////////////////////////
+// LocalTransformComponent is the transform of a child entity relative to its
+// parent, TransformPropagationSystem writes the resulting world transform.
+type LocalTransformComponent struct {
+	Position vector.Vec2
+	Scale    vector.Vec2
+	Rotation float32
+}
+
+func (c LocalTransformComponent) String() string {
+	return "LocalTransformComponent"
+}
+
 ///////////////////////////////////////////////////
 type BoxColliderComponent struct {
 	Width  float32
@@ -80,10 +140,6 @@ type BoxColliderComponent struct {
 	Offset vector.Vec2
 }
 
-func (c BoxColliderComponent) GetID() int {
-	return int(BOX_COLLIDER_COMPONENT)
-}
-
 func (c BoxColliderComponent) String() string {
 	return "BoxColliderComponent"
 }
@@ -93,21 +149,18 @@ type RigidbodyComponent struct {
 	Velocity vector.Vec2
 }
 
-func (c RigidbodyComponent) GetID() int {
-	return int(RIGIDBODY_COMPONENT)
-}
-
 func (c RigidbodyComponent) String() string {
 	return "RigidBodyComponent"
 }
 
 ///////////////////////////////////////////////////
 type AnimationComponent struct {
-	numFrames      int
-	currentFrame   int
-	frameRateSpeed int // ms
-	loop           bool
-	startTime      uint32
+	NumFrames      int
+	CurrentFrame   int
+	FrameRateSpeed int // ms
+	Loop           bool
+	// game time the entity joined AnimationSystem at
+	StartTime time.Duration
 }
 
 func NewAnimationComponent(numFrames, frameRateSpeed int, loop bool) AnimationComponent {
@@ -115,32 +168,23 @@ func NewAnimationComponent(numFrames, frameRateSpeed int, loop bool) AnimationCo
 		panic("invalid parameter")
 	}
 	return AnimationComponent{
-		numFrames:      numFrames,
-		currentFrame:   0,
-		frameRateSpeed: frameRateSpeed,
-		loop:           loop,
-		startTime:      sdl.GetTicks(),
+		NumFrames:      numFrames,
+		CurrentFrame:   0,
+		FrameRateSpeed: frameRateSpeed,
+		Loop:           loop,
 	}
 }
 
-func (c AnimationComponent) GetID() int {
-	return int(ANIMATION_COMPONENT)
-}
-
 func (c AnimationComponent) String() string {
 	return "AnimationComponent"
 }
 
 //////////////////////////////////////////////////
 type KeyboardControlledComponent struct {
-	upVelocity    vector.Vec2
-	downVelocity  vector.Vec2
-	leftVelocity  vector.Vec2
-	rightVelocity vector.Vec2
-}
-
-func (c KeyboardControlledComponent) GetID() int {
-	return int(KEYBOARD_CONTROLLED_COMPONENT)
+	UpVelocity    vector.Vec2
+	DownVelocity  vector.Vec2
+	LeftVelocity  vector.Vec2
+	RightVelocity vector.Vec2
 }
 
 func (c KeyboardControlledComponent) String() string {
@@ -150,10 +194,6 @@ func (c KeyboardControlledComponent) String() string {
 //////////////////////////////////////////////////
 type CameraFollowComponent struct{}
 
-func (c CameraFollowComponent) GetID() int {
-	return int(CAMERA_FOLLOW_COMPONENT)
-}
-
 func (c CameraFollowComponent) String() string {
 	return "CameraFollowComponent"
 }
@@ -161,10 +201,6 @@ func (c CameraFollowComponent) String() string {
 //////////////////////////////////////////////////
 type TankSpawnerComponent struct{}
 
-func (c TankSpawnerComponent) GetID() int {
-	return int(TANK_SPAWNER_COMPONENT)
-}
-
 func (c TankSpawnerComponent) String() string {
 	return "TankSpawnerComponent"
 }
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubil6y/go_game_engine/pkg/ecs"
+	"github.com/kubil6y/go_game_engine/pkg/engine"
+	"github.com/kubil6y/go_game_engine/pkg/logger"
+	"github.com/kubil6y/go_game_engine/pkg/vector"
+)
+
+// newTestGame loads the level in a headless App. The asset root holds the
+// prefabs of the game and a blank map, textures are skipped when headless.
+func newTestGame(t *testing.T) *Game {
+	root := t.TempDir()
+	prefabs, err := os.ReadFile(filepath.Join("..", "..", "assets", "prefabs", "tanks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := strings.Repeat("00,", mapNumCols-1) + "00\n"
+	files := map[string][]byte{
+		"prefabs/tanks.json":  prefabs,
+		"tilemaps/jungle.map": []byte(strings.Repeat(row, mapNumRows)),
+	}
+	for name, data := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	app := engine.New(
+		engine.WithHeadless(),
+		engine.WithAssetRoot(root),
+		engine.WithTickRate(TICK_RATE),
+		engine.WithMaxComponents(MAX_COMPONENTS_AMOUNT),
+		engine.WithLogger(logger.New(logger.WithLogLevel(logger.LEVEL_OFF))),
+	)
+	g := NewGame(app, false)
+	if err := app.Initialize(); err != nil {
+		t.Fatalf("Unexpected error initializing: %v", err)
+	}
+	t.Cleanup(app.Destroy)
+	if _, err := app.RunHeadless(1, nil); err != nil {
+		t.Fatalf("Unexpected error loading the level: %v", err)
+	}
+	return g
+}
+
+func TestSaveLoadedLevel(t *testing.T) {
+	g := newTestGame(t)
+	for _, format := range []ecs.SnapshotFormat{ecs.SnapshotJSON, ecs.SnapshotBinary} {
+		var buf bytes.Buffer
+		if err := g.registry.Save(&buf, format); err != nil {
+			t.Fatalf("format %d: unexpected error saving the level: %v", format, err)
+		}
+		loaded := ecs.NewRegistry(MAX_COMPONENTS_AMOUNT, g.app.Logger())
+		if err := RegisterComponentCodecs(loaded); err != nil {
+			t.Fatal(err)
+		}
+		if err := loaded.Load(&buf, format); err != nil {
+			t.Fatalf("format %d: unexpected error loading the level: %v", format, err)
+		}
+
+		player, ok := loaded.FindByName("player")
+		if !ok {
+			t.Fatalf("format %d: expected the player to be restored", format)
+		}
+		if keyboard, _ := ecs.Get[KeyboardControlledComponent](loaded, player); keyboard == nil || keyboard.UpVelocity != (vector.Vec2{X: 0, Y: -120}) {
+			t.Errorf("format %d: expected the keyboard velocities to be restored, got %+v", format, keyboard)
+		}
+		if animation, _ := ecs.Get[AnimationComponent](loaded, player); animation == nil || animation.NumFrames != 2 || !animation.Loop {
+			t.Errorf("format %d: expected the animation to be restored, got %+v", format, animation)
+		}
+	}
+}
+
+func TestSpritesNameTheirAsset(t *testing.T) {
+	g := newTestGame(t)
+	tank, err := g.registry.Spawn("tank")
+	if err != nil {
+		t.Fatalf("Unexpected error spawning: %v", err)
+	}
+	sprite, _ := ecs.Get[SpriteComponent](g.registry, tank)
+	if sprite == nil || sprite.AssetID != IMG_Tank {
+		t.Fatalf("Expected the tank prefab to use the tank asset, got %+v", sprite)
+	}
+
+	data, err := json.Marshal(sprite)
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling: %v", err)
+	}
+	var decoded SpriteComponent
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded != *sprite {
+		t.Errorf("Expected %s to decode to %+v, got %+v (%v)", data, *sprite, decoded, err)
+	}
+	if err := json.Unmarshal([]byte(`{"Asset": "boat"}`), &decoded); err == nil {
+		t.Error("Expected an unknown asset name to be an error")
+	}
+}
+
+func TestTankSpawnerForgetsRemovedSpawners(t *testing.T) {
+	g := newTestGame(t)
+	spawners := g.registry.GetSystem(TANK_SPAWNER_SYSTEM).(*TankSpawnerSystem)
+	if len(spawners.spawnTimers) != 1 {
+		t.Fatalf("Expected the spawner of the level to have a timer, got %d", len(spawners.spawnTimers))
+	}
+	for _, spawner := range spawners.GetSystemEntities() {
+		g.registry.KillEntity(spawner)
+	}
+	g.registry.Update()
+	if len(spawners.spawnTimers) != 0 {
+		t.Errorf("Expected the timers of killed spawners to be removed, got %d", len(spawners.spawnTimers))
+	}
+}
+
+func TestTransformPropagation(t *testing.T) {
+	g := newTestGame(t)
+	r := g.registry
+	newEntity := func(tf TransformComponent, local *LocalTransformComponent, parent ecs.Entity) ecs.Entity {
+		entity := r.CreateEntity()
+		ecs.Add(r, entity, tf)
+		if local != nil {
+			ecs.Add(r, entity, *local)
+		}
+		if parent != (ecs.Entity{}) {
+			if err := ecs.SetParent(r, entity, parent); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return entity
+	}
+	one := vector.Vec2{X: 1, Y: 1}
+
+	// root is a rotated and scaled parent, its child and grandchild follow it
+	root := newEntity(TransformComponent{Position: vector.Vec2{X: 100, Y: 50}, Scale: vector.Vec2{X: 2, Y: 2}, Rotation: 90}, nil, ecs.Entity{})
+	child := newEntity(TransformComponent{}, &LocalTransformComponent{Position: vector.Vec2{X: 10, Y: 0}, Scale: vector.Vec2{X: 1.5, Y: 1}, Rotation: 10}, root)
+	grandchild := newEntity(TransformComponent{}, &LocalTransformComponent{Position: vector.Vec2{X: 5, Y: 0}, Scale: one}, child)
+	// orphan has a parent without a LocalTransformComponent
+	orphanParent := newEntity(TransformComponent{Position: vector.Vec2{X: 10, Y: 10}, Scale: one}, nil, ecs.Entity{})
+	orphan := newEntity(TransformComponent{}, &LocalTransformComponent{Position: vector.Vec2{X: 3, Y: 4}, Scale: one, Rotation: 45}, orphanParent)
+
+	r.Update()
+	r.GetSystem(TRANSFORM_PROPAGATION_SYSTEM).Update(0)
+
+	tests := []struct {
+		name   string
+		entity ecs.Entity
+		want   TransformComponent
+	}{
+		{"child", child, TransformComponent{Position: vector.Vec2{X: 100, Y: 70}, Scale: vector.Vec2{X: 3, Y: 2}, Rotation: 100}},
+		{"grandchild", grandchild, TransformComponent{Position: vector.Vec2{X: 97.3953, Y: 84.7721}, Scale: vector.Vec2{X: 3, Y: 2}, Rotation: 100}},
+		{"orphan", orphan, TransformComponent{Position: vector.Vec2{X: 13, Y: 14}, Scale: one, Rotation: 45}},
+	}
+	near := func(a, b float32) bool {
+		return math.Abs(float64(a-b)) < 1e-3
+	}
+	for _, tt := range tests {
+		tf, err := ecs.Get[TransformComponent](r, tt.entity)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !near(tf.Position.X, tt.want.Position.X) || !near(tf.Position.Y, tt.want.Position.Y) {
+			t.Errorf("%s: expected position %+v, got %+v", tt.name, tt.want.Position, tf.Position)
+		}
+		if !near(tf.Scale.X, tt.want.Scale.X) || !near(tf.Scale.Y, tt.want.Scale.Y) {
+			t.Errorf("%s: expected scale %+v, got %+v", tt.name, tt.want.Scale, tf.Scale)
+		}
+		if !near(tf.Rotation, tt.want.Rotation) {
+			t.Errorf("%s: expected rotation %v, got %v", tt.name, tt.want.Rotation, tf.Rotation)
+		}
+	}
+}
+
+func TestPauseStopsGameTime(t *testing.T) {
+	g := newTestGame(t)
+	g.setPaused(true)
+	if !g.app.IsPaused() || g.registry.IsGroupEnabled(GROUP_SIMULATION) {
+		t.Errorf("Expected pausing to stop the game clock and the simulation")
+	}
+	g.setPaused(false)
+	if g.app.IsPaused() || !g.registry.IsGroupEnabled(GROUP_SIMULATION) {
+		t.Errorf("Expected unpausing to restart the game clock and the simulation")
+	}
+}
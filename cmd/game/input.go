@@ -0,0 +1,93 @@
+//go:build !headless
+
+package main
+
+import (
+	"github.com/kubil6y/go_game_engine/pkg/ecs"
+	"github.com/kubil6y/go_game_engine/pkg/engine"
+	"github.com/kubil6y/go_game_engine/pkg/eventbus"
+	"github.com/kubil6y/go_game_engine/pkg/logger"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type KeydownEvent struct {
+	Keysym sdl.Keysym
+}
+
+func (g *Game) ProcessEvent(app *engine.App, event sdl.Event) {
+	switch t := event.(type) {
+	case *sdl.KeyboardEvent:
+		if t.State == sdl.PRESSED {
+			eventbus.Publish(app.Events(), KeydownEvent{
+				Keysym: t.Keysym,
+			})
+
+			switch t.Keysym.Sym {
+			case sdl.K_ESCAPE:
+				app.Logger().Debug("Quitting with escape", nil)
+				app.Quit()
+			case sdl.K_o:
+				g.debug = !g.debug
+				g.registry.SetGroupEnabled(GROUP_DEBUG, g.debug)
+			case sdl.K_p:
+				g.setPaused(!g.paused)
+			case sdl.K_MINUS:
+				g.setTimeScale(app.TimeScale() / 2)
+			case sdl.K_EQUALS:
+				g.setTimeScale(app.TimeScale() * 2)
+			}
+		}
+	}
+}
+
+// KeyboardControl SYSTEM ////////////////////////////////////////////////
+type KeyboardControlSystem struct {
+	*ecs.BaseSystem
+}
+
+func NewKeyboardControlSystem(logger *logger.Logger, registry *ecs.Registry) *KeyboardControlSystem {
+	s := &KeyboardControlSystem{
+		BaseSystem: ecs.NewBaseSystem("KeyboardControlSystem", logger, registry),
+	}
+	ecs.RequireComponent[SpriteComponent](s.BaseSystem)
+	ecs.RequireComponent[RigidbodyComponent](s.BaseSystem)
+	ecs.RequireComponent[KeyboardControlledComponent](s.BaseSystem)
+	return s
+}
+
+func (s KeyboardControlSystem) GetName() string {
+	return s.Name
+}
+
+func (s *KeyboardControlSystem) SubscribeToEvents() {
+	events, err := ecs.GetResource[*eventbus.EventBus](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "KeyboardControlSystem: missing event bus", nil)
+		return
+	}
+	s.AddTeardown(eventbus.Subscribe(events, s.OnKeydown).Unsubscribe)
+}
+
+func (s *KeyboardControlSystem) Update(dt float32) {
+}
+
+func (s *KeyboardControlSystem) OnKeydown(event KeydownEvent) {
+	for _, c := range ecs.Iter3[KeyboardControlledComponent, SpriteComponent, RigidbodyComponent](s.GetQuery()) {
+		keyboard, sprite, rb := c.A, c.B, c.C
+
+		switch event.Keysym.Sym {
+		case sdl.K_UP:
+			rb.Velocity = keyboard.UpVelocity
+			sprite.SrcRect.Y = int32(sprite.Height * 0)
+		case sdl.K_RIGHT:
+			rb.Velocity = keyboard.RightVelocity
+			sprite.SrcRect.Y = int32(sprite.Height * 1)
+		case sdl.K_DOWN:
+			rb.Velocity = keyboard.DownVelocity
+			sprite.SrcRect.Y = int32(sprite.Height * 2)
+		case sdl.K_LEFT:
+			rb.Velocity = keyboard.LeftVelocity
+			sprite.SrcRect.Y = int32(sprite.Height * 3)
+		}
+	}
+}
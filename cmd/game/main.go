@@ -1,13 +1,58 @@
 package main
 
-import "os"
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/kubil6y/go_game_engine/pkg/config"
+	"github.com/kubil6y/go_game_engine/pkg/engine"
+	"github.com/kubil6y/go_game_engine/pkg/logger"
+)
 
 func main() {
-	game := NewGame()
-	if err := game.Initialize(); err != nil {
-		game.logger.Fatal(err, "something is terribly wrong", nil)
+	headless := flag.Bool("headless", false, "run the simulation without a window")
+	ticks := flag.Int("ticks", 60*TICK_RATE, "simulation steps to run in headless mode")
+	cfg, err := config.Load(DefaultConfig(), flag.CommandLine, os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	logger := logger.New(logger.WithLogLevel(cfg.Level()))
+	opts := []engine.Option{
+		engine.WithConfig(cfg),
+		engine.WithTickRate(TICK_RATE),
+		engine.WithMaxComponents(MAX_COMPONENTS_AMOUNT),
+		engine.WithLogger(logger),
+	}
+	if *headless {
+		opts = append(opts, engine.WithHeadless())
+	}
+	app := engine.New(opts...)
+	game := NewGame(app, cfg.Debug)
+	if err := app.Initialize(); err != nil {
+		logger.Fatal(err, "something is terribly wrong", nil)
 		os.Exit(1)
 	}
-	defer game.Destroy()
-	game.Run()
+	defer app.Destroy()
+
+	if *headless {
+		ran, err := app.RunHeadless(*ticks, nil)
+		if err != nil {
+			logger.Fatal(err, "failed to run", nil)
+		}
+		logger.Info("Headless run finished", map[string]any{
+			"ticks":   ran,
+			"enemies": len(game.registry.EntitiesWithTag("enemy")),
+		})
+		return
+	}
+	if err := app.Run(); err != nil {
+		logger.Fatal(err, "failed to run", nil)
+	}
 }
@@ -0,0 +1,18 @@
+//go:build headless
+
+package main
+
+import (
+	"github.com/kubil6y/go_game_engine/pkg/ecs"
+	"github.com/kubil6y/go_game_engine/pkg/engine"
+)
+
+// addPlatformSystems adds nothing, builds without SDL neither draw nor read
+// input.
+func (g *Game) addPlatformSystems(addSystem func(systemID ecs.SystemTypeID, system ecs.System, opts ...ecs.SystemOption)) {
+}
+
+func (g *Game) loadTextures() {}
+
+// ProcessEvent is never called, builds without SDL poll no events.
+func (g *Game) ProcessEvent(app *engine.App, event engine.Event) {}
@@ -0,0 +1,27 @@
+//go:build !headless
+
+package main
+
+import (
+	"github.com/kubil6y/go_game_engine/pkg/ecs"
+	"github.com/kubil6y/go_game_engine/pkg/engine"
+)
+
+// addPlatformSystems adds the systems that draw or read input with SDL.
+func (g *Game) addPlatformSystems(addSystem func(systemID ecs.SystemTypeID, system ecs.System, opts ...ecs.SystemOption)) {
+	logger := g.app.Logger()
+	addSystem(KEYBOARD_CONTROL_SYSTEM, NewKeyboardControlSystem(logger, g.registry))
+	addSystem(RENDER_SYSTEM, NewRenderSystem(logger, g.registry), ecs.InStage(ecs.StageRender), ecs.InGroup(engine.GROUP_GRAPHICS))
+	addSystem(RENDER_COLLISION_SYSTEM, NewRenderCollisionSystem(logger, g.registry), ecs.InStage(ecs.StageRender),
+		ecs.InGroup(engine.GROUP_GRAPHICS, GROUP_DEBUG), ecs.After(RENDER_SYSTEM))
+}
+
+func (g *Game) loadTextures() {
+	if g.app.IsHeadless() {
+		return
+	}
+	renderer, assetStore := g.app.Renderer(), g.app.AssetStore()
+	assetStore.AddTexture(renderer, IMG_Tilemap, g.app.AssetPath("tilemaps/jungle.png"))
+	assetStore.AddTexture(renderer, IMG_Tank, g.app.AssetPath("images/tank-panther-left.png"))
+	assetStore.AddTexture(renderer, IMG_Chopper, g.app.AssetPath("images/chopper-spritesheet.png"))
+}
@@ -0,0 +1,169 @@
+//go:build !headless
+
+package main
+
+import (
+	"slices"
+
+	"github.com/kubil6y/go_game_engine/pkg/asset_store"
+	"github.com/kubil6y/go_game_engine/pkg/ecs"
+	"github.com/kubil6y/go_game_engine/pkg/engine"
+	"github.com/kubil6y/go_game_engine/pkg/logger"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// RENDER SYSTEM ////////////////////////////////////////////////
+type RenderSystem struct {
+	*ecs.BaseSystem
+	// entities sorted by z-index, rebuilt when sprites or members change
+	sorted []ecs.Entity
+	dirty  bool
+}
+
+func NewRenderSystem(logger *logger.Logger, registry *ecs.Registry) *RenderSystem {
+	s := &RenderSystem{
+		BaseSystem: ecs.NewBaseSystem("RenderSystem", logger, registry),
+		sorted:     make([]ecs.Entity, 0),
+	}
+	ecs.RequireComponent[SpriteComponent](s.BaseSystem)
+	ecs.RequireComponent[TransformComponent](s.BaseSystem)
+	return s
+}
+
+func (s RenderSystem) GetName() string {
+	return s.Name
+}
+
+func (s *RenderSystem) AddEntityToSystem(entity ecs.Entity) {
+	s.BaseSystem.AddEntityToSystem(entity)
+	s.dirty = true
+}
+
+func (s *RenderSystem) RemoveEntityFromSystem(entity ecs.Entity) {
+	if s.HasEntity(entity) {
+		s.BaseSystem.RemoveEntityFromSystem(entity)
+		s.dirty = true
+	}
+}
+
+func (s *RenderSystem) SubscribeToEvents() {
+	remove, err := ecs.OnSet[SpriteComponent](s.Registry, func(r *ecs.Registry, entity ecs.Entity) {
+		s.dirty = true
+	})
+	if err != nil {
+		s.Logger.Error(err, "RenderSystem: failed to watch sprites", nil)
+		return
+	}
+	s.AddTeardown(remove)
+}
+
+func (s *RenderSystem) sortByZIndex() {
+	s.sorted = append(s.sorted[:0], s.GetSystemEntities()...)
+	slices.SortStableFunc(s.sorted, func(a, b ecs.Entity) int {
+		spriteA, _ := ecs.Get[SpriteComponent](s.Registry, a)
+		spriteB, _ := ecs.Get[SpriteComponent](s.Registry, b)
+		return spriteA.ZIndex - spriteB.ZIndex
+	})
+	s.dirty = false
+}
+
+func (s *RenderSystem) Update(dt float32) {
+	renderer, err := ecs.GetResource[*sdl.Renderer](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "RenderSystem: missing renderer", nil)
+		return
+	}
+	assetStore, err := ecs.GetResource[*asset_store.AssetStore](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "RenderSystem: missing asset store", nil)
+		return
+	}
+	camera, err := ecs.GetResource[*engine.Rect](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "RenderSystem: missing camera", nil)
+		return
+	}
+	interpolation, err := ecs.GetResource[engine.Interpolation](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "RenderSystem: missing interpolation", nil)
+		return
+	}
+
+	if s.dirty {
+		s.sortByZIndex()
+	}
+	for _, entity := range s.sorted {
+		sprite, err := ecs.Get[SpriteComponent](s.Registry, entity)
+		if err != nil {
+			continue
+		}
+		tf, err := ecs.Get[TransformComponent](s.Registry, entity)
+		if err != nil {
+			continue
+		}
+
+		var cameraOffsetX float32
+		var cameraOffsetY float32
+		if !sprite.IsFixed {
+			cameraOffsetX = float32(camera.X)
+			cameraOffsetY = float32(camera.Y)
+		}
+
+		position := tf.Interpolated(interpolation.Alpha)
+		var dstRect sdl.Rect
+		dstRect.X = int32(position.X - cameraOffsetX)
+		dstRect.Y = int32(position.Y - cameraOffsetY)
+		dstRect.W = int32(sprite.Width * int(tf.Scale.X))
+		dstRect.H = int32(sprite.Height * int(tf.Scale.Y))
+		renderer.CopyEx(assetStore.GetTexture(sprite.AssetID), &sprite.SrcRect, &dstRect, 0, nil, sdl.FLIP_NONE)
+	}
+}
+
+// RENDER COLLISION SYSTEM ////////////////////////////////////////////////
+type RenderCollisionSystem struct {
+	*ecs.BaseSystem
+}
+
+func NewRenderCollisionSystem(logger *logger.Logger, registry *ecs.Registry) *RenderCollisionSystem {
+	s := &RenderCollisionSystem{
+		BaseSystem: ecs.NewBaseSystem("RenderCollisionSystem", logger, registry),
+	}
+	ecs.RequireComponent[TransformComponent](s.BaseSystem)
+	ecs.RequireComponent[BoxColliderComponent](s.BaseSystem)
+	return s
+}
+
+func (s RenderCollisionSystem) GetName() string {
+	return s.Name
+}
+
+func (s *RenderCollisionSystem) Update(dt float32) {
+	renderer, err := ecs.GetResource[*sdl.Renderer](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "RenderCollisionSystem: missing renderer", nil)
+		return
+	}
+	camera, err := ecs.GetResource[*engine.Rect](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "RenderCollisionSystem: missing camera", nil)
+		return
+	}
+	interpolation, err := ecs.GetResource[engine.Interpolation](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "RenderCollisionSystem: missing interpolation", nil)
+		return
+	}
+
+	for _, c := range ecs.Iter2[TransformComponent, BoxColliderComponent](s.GetQuery()) {
+		tf, col := c.A, c.B
+		position := tf.Interpolated(interpolation.Alpha)
+		rect := sdl.Rect{
+			X: int32(position.X + col.Offset.X - float32(camera.X)),
+			Y: int32(position.Y + col.Offset.Y - float32(camera.Y)),
+			W: int32(tf.Scale.X * col.Width),
+			H: int32(tf.Scale.Y * col.Height),
+		}
+		renderer.SetDrawColor(255, 0, 0, 255)
+		renderer.DrawRect(&rect)
+	}
+}
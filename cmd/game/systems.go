@@ -2,17 +2,17 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
 	"time"
 
 	"github.com/kubil6y/go_game_engine/internal/utils"
-	"github.com/kubil6y/go_game_engine/pkg/asset_store"
-	"github.com/kubil6y/go_game_engine/pkg/bitset"
+	"github.com/kubil6y/go_game_engine/pkg/clock"
 	"github.com/kubil6y/go_game_engine/pkg/ecs"
+	"github.com/kubil6y/go_game_engine/pkg/engine"
 	"github.com/kubil6y/go_game_engine/pkg/eventbus"
 	"github.com/kubil6y/go_game_engine/pkg/logger"
 	"github.com/kubil6y/go_game_engine/pkg/vector"
-	"github.com/veandco/go-sdl2/sdl"
 )
 
 const (
@@ -25,140 +25,202 @@ const (
 	KEYBOARD_CONTROL_SYSTEM
 	CAMERA_MOVEMENT_SYSTEM
 	TANK_SPAWNER_SYSTEM
+	TRANSFORM_PROPAGATION_SYSTEM
+	TRANSFORM_HISTORY_SYSTEM
 )
 
-// RENDER SYSTEM ////////////////////////////////////////////////
-type RenderSystem struct {
+const (
+	GROUP_SIMULATION = "simulation"
+	GROUP_DEBUG      = "debug"
+)
+
+// MOVEMENT SYSTEM ////////////////////////////////////////////////
+type MovementSystem struct {
 	*ecs.BaseSystem
-	renderer   *sdl.Renderer
-	assetStore *asset_store.AssetStore
-	camera     *sdl.Rect
 }
 
-func NewRenderSystem(logger *logger.Logger, registry *ecs.Registry, renderer *sdl.Renderer, assetStore *asset_store.AssetStore, camera *sdl.Rect) *RenderSystem {
-	bs := bitset.NewBitset32()
-	bs.Set(int(SPRITE_COMPONENT))
-	bs.Set(int(TRANSFORM_COMPONENT))
+func NewMovementSystem(logger *logger.Logger, registry *ecs.Registry) *MovementSystem {
+	s := &MovementSystem{
+		BaseSystem: ecs.NewBaseSystem("MovementSystem", logger, registry),
+	}
+	ecs.RequireComponent[RigidbodyComponent](s.BaseSystem)
+	ecs.RequireComponent[TransformComponent](s.BaseSystem)
+	return s
+}
+
+func (s MovementSystem) GetName() string {
+	return s.Name
+}
+
+func (s *MovementSystem) Update(dt float32) {
+	for _, c := range ecs.Iter2[TransformComponent, RigidbodyComponent](s.GetQuery()) {
+		tf, rb := c.A, c.B
+		tf.Position.X += rb.Velocity.X * dt
+		tf.Position.Y += rb.Velocity.Y * dt
+	}
+}
+
+// TRANSFORM PROPAGATION SYSTEM ////////////////////////////////////////////////
+type TransformPropagationSystem struct {
+	*ecs.BaseSystem
+}
 
-	return &RenderSystem{
-		BaseSystem: ecs.NewBaseSystem("RenderSystem", logger, registry, bs),
-		renderer:   renderer,
-		assetStore: assetStore,
-		camera:     camera,
+func NewTransformPropagationSystem(logger *logger.Logger, registry *ecs.Registry) *TransformPropagationSystem {
+	s := &TransformPropagationSystem{
+		BaseSystem: ecs.NewBaseSystem("TransformPropagationSystem", logger, registry),
 	}
+	ecs.RequireComponent[LocalTransformComponent](s.BaseSystem)
+	ecs.RequireComponent[TransformComponent](s.BaseSystem)
+	ecs.RequireComponent[ecs.Parent](s.BaseSystem)
+	return s
 }
 
-func (s RenderSystem) GetName() string {
+func (s TransformPropagationSystem) GetName() string {
 	return s.Name
 }
 
-func (s *RenderSystem) Update(dt float32) {
-	var currZIndex int
-	var maxZIndex int
+func (s *TransformPropagationSystem) Update(dt float32) {
+	q := s.GetQuery()
+	for entity := range q.All() {
+		parent, _ := ecs.GetParent(s.Registry, entity)
+		// entities whose parent is propagated too are updated after it
+		if q.Has(parent) {
+			continue
+		}
+		s.propagate(entity, parent)
+	}
+}
 
-	for currZIndex <= maxZIndex {
-		for _, entity := range s.GetSystemEntities() {
-			sprite := s.Registry.GetComponentPtr(entity, SPRITE_COMPONENT).(*SpriteComponent)
-			if maxZIndex < sprite.ZIndex {
-				maxZIndex = sprite.ZIndex
-			}
-			if currZIndex != sprite.ZIndex {
-				continue
-			}
-			tf := s.Registry.GetComponentPtr(entity, TRANSFORM_COMPONENT).(*TransformComponent)
+func (s *TransformPropagationSystem) propagate(entity, parent ecs.Entity) {
+	parentTf, err := ecs.Get[TransformComponent](s.Registry, parent)
+	if err != nil {
+		return
+	}
+	local, err := ecs.Get[LocalTransformComponent](s.Registry, entity)
+	if err != nil {
+		return
+	}
+	tf, err := ecs.Get[TransformComponent](s.Registry, entity)
+	if err != nil {
+		return
+	}
 
-			var cameraOffsetX float32
-			var cameraOffsetY float32
-			if !sprite.IsFixed {
-				cameraOffsetX = float32(s.camera.X)
-				cameraOffsetY = float32(s.camera.Y)
-			}
+	offset := vector.Vec2{
+		X: local.Position.X * parentTf.Scale.X,
+		Y: local.Position.Y * parentTf.Scale.Y,
+	}
+	sin, cos := math.Sincos(float64(parentTf.Rotation) * math.Pi / 180)
+	tf.Position = vector.Vec2{
+		X: parentTf.Position.X + offset.X*float32(cos) - offset.Y*float32(sin),
+		Y: parentTf.Position.Y + offset.X*float32(sin) + offset.Y*float32(cos),
+	}
+	tf.Scale = vector.Vec2{
+		X: local.Scale.X * parentTf.Scale.X,
+		Y: local.Scale.Y * parentTf.Scale.Y,
+	}
+	tf.Rotation = parentTf.Rotation + local.Rotation
 
-			var dstRect sdl.Rect
-			dstRect.X = int32(tf.Position.X - cameraOffsetX)
-			dstRect.Y = int32(tf.Position.Y - cameraOffsetY)
-			dstRect.W = int32(sprite.Width * int(tf.Scale.X))
-			dstRect.H = int32(sprite.Height * int(tf.Scale.Y))
-			s.renderer.CopyEx(s.assetStore.GetTexture(sprite.AssetID), &sprite.SrcRect, &dstRect, 0, nil, sdl.FLIP_NONE)
+	for _, child := range ecs.GetChildren(s.Registry, entity) {
+		if s.GetQuery().Has(child) {
+			s.propagate(child, entity)
 		}
-		currZIndex++
 	}
 }
 
-// MOVEMENT SYSTEM ////////////////////////////////////////////////
-type MovementSystem struct {
+// TRANSFORM HISTORY SYSTEM ////////////////////////////////////////////////
+// TransformHistorySystem remembers where entities were before each
+// simulation step so renderers can interpolate between steps.
+type TransformHistorySystem struct {
 	*ecs.BaseSystem
 }
 
-func NewMovementSystem(logger *logger.Logger, registry *ecs.Registry) *MovementSystem {
-	bs := bitset.NewBitset32()
-	bs.Set(int(RIGIDBODY_COMPONENT))
-	bs.Set(int(TRANSFORM_COMPONENT))
-	return &MovementSystem{
-		BaseSystem: ecs.NewBaseSystem("MovementSystem", logger, registry, bs),
+func NewTransformHistorySystem(logger *logger.Logger, registry *ecs.Registry) *TransformHistorySystem {
+	s := &TransformHistorySystem{
+		BaseSystem: ecs.NewBaseSystem("TransformHistorySystem", logger, registry),
 	}
+	ecs.RequireComponent[TransformComponent](s.BaseSystem)
+	return s
 }
 
-func (s MovementSystem) GetName() string {
+func (s TransformHistorySystem) GetName() string {
 	return s.Name
 }
 
-func (s *MovementSystem) Update(dt float32) {
-	for _, entity := range s.GetSystemEntities() {
-		tf := s.Registry.GetComponentPtr(entity, TRANSFORM_COMPONENT).(*TransformComponent)
-		rb := s.Registry.GetComponentPtr(entity, RIGIDBODY_COMPONENT).(*RigidbodyComponent)
-		tf.Position.X += rb.Velocity.X * dt
-		tf.Position.Y += rb.Velocity.Y * dt
+// AddEntityToSystem starts new entities at rest, they would otherwise be
+// drawn sliding in from the origin.
+func (s *TransformHistorySystem) AddEntityToSystem(entity ecs.Entity) {
+	s.BaseSystem.AddEntityToSystem(entity)
+	if tf, err := ecs.Get[TransformComponent](s.Registry, entity); err == nil {
+		tf.PreviousPosition = tf.Position
+	}
+}
+
+func (s *TransformHistorySystem) Update(dt float32) {
+	for _, tf := range ecs.Iter[TransformComponent](s.GetQuery()) {
+		tf.PreviousPosition = tf.Position
 	}
 }
 
 // ANIMATION SYSTEM ////////////////////////////////////////////////
 type AnimationSystem struct {
 	*ecs.BaseSystem
-	renderer   *sdl.Renderer
-	assetStore *asset_store.AssetStore
 }
 
 func NewAnimationSystem(logger *logger.Logger, registry *ecs.Registry) *AnimationSystem {
-	bs := bitset.NewBitset32()
-	bs.Set(int(SPRITE_COMPONENT))
-	bs.Set(int(ANIMATION_COMPONENT))
-	return &AnimationSystem{
-		BaseSystem: ecs.NewBaseSystem("AnimationSystem", logger, registry, bs),
+	s := &AnimationSystem{
+		BaseSystem: ecs.NewBaseSystem("AnimationSystem", logger, registry),
 	}
+	ecs.RequireComponent[SpriteComponent](s.BaseSystem)
+	ecs.RequireComponent[AnimationComponent](s.BaseSystem)
+	return s
 }
 
 func (s AnimationSystem) GetName() string {
 	return s.Name
 }
 
+func (s *AnimationSystem) AddEntityToSystem(entity ecs.Entity) {
+	s.BaseSystem.AddEntityToSystem(entity)
+	gameTime, err := ecs.GetResource[clock.Clock](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "AnimationSystem: missing clock", nil)
+		return
+	}
+	if animation, err := ecs.Get[AnimationComponent](s.Registry, entity); err == nil {
+		animation.StartTime = gameTime.Now()
+	}
+}
+
 func (s *AnimationSystem) Update(dt float32) {
-	for _, entity := range s.GetSystemEntities() {
-		sprite := s.Registry.GetComponentPtr(entity, SPRITE_COMPONENT).(*SpriteComponent)
-		animation := s.Registry.GetComponentPtr(entity, ANIMATION_COMPONENT).(*AnimationComponent)
+	gameTime, err := ecs.GetResource[clock.Clock](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "AnimationSystem: missing clock", nil)
+		return
+	}
+
+	for _, c := range ecs.Iter2[SpriteComponent, AnimationComponent](s.GetQuery()) {
+		sprite, animation := c.A, c.B
 
 		// TODO support loop
-		animation.currentFrame = int((sdl.GetTicks() - animation.startTime)) *
-			animation.frameRateSpeed / 1000 %
-			animation.numFrames
-		sprite.SrcRect.X = int32(animation.currentFrame * sprite.Width)
+		animation.CurrentFrame = int((gameTime.Now() - animation.StartTime).Milliseconds()) *
+			animation.FrameRateSpeed / 1000 %
+			animation.NumFrames
+		sprite.SrcRect.X = int32(animation.CurrentFrame * sprite.Width)
 	}
 }
 
 // COLLISION SYSTEM ////////////////////////////////////////////////
 type CollisionSystem struct {
 	*ecs.BaseSystem
-	events *eventbus.EventBus
 }
 
-func NewCollisionSystem(logger *logger.Logger, registry *ecs.Registry, events *eventbus.EventBus) *CollisionSystem {
-	bs := bitset.NewBitset32()
-	bs.Set(int(TRANSFORM_COMPONENT))
-	bs.Set(int(BOX_COLLIDER_COMPONENT))
-	return &CollisionSystem{
-		BaseSystem: ecs.NewBaseSystem("CollisionSystem", logger, registry, bs),
-		events:     events,
+func NewCollisionSystem(logger *logger.Logger, registry *ecs.Registry) *CollisionSystem {
+	s := &CollisionSystem{
+		BaseSystem: ecs.NewBaseSystem("CollisionSystem", logger, registry),
 	}
+	ecs.RequireComponent[TransformComponent](s.BaseSystem)
+	ecs.RequireComponent[BoxColliderComponent](s.BaseSystem)
+	return s
 }
 
 func (s CollisionSystem) GetName() string {
@@ -166,24 +228,24 @@ func (s CollisionSystem) GetName() string {
 }
 
 func (s *CollisionSystem) Update(dt float32) {
-	entities := s.GetSystemEntities()
-	for i := 0; i < len(entities); i++ {
-		a := entities[i]
-		atf := s.Registry.GetComponentPtr(a, TRANSFORM_COMPONENT).(*TransformComponent)
-		acol := s.Registry.GetComponentPtr(a, BOX_COLLIDER_COMPONENT).(*BoxColliderComponent)
-		for j := 0; j < len(entities); j++ {
-			b := entities[j]
-			if a.GetID() == b.GetID() {
+	events, err := ecs.GetResource[*eventbus.EventBus](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "CollisionSystem: missing event bus", nil)
+		return
+	}
+
+	colliders := ecs.Iter2[TransformComponent, BoxColliderComponent](s.GetQuery())
+	for a, ac := range colliders {
+		for b, bc := range colliders {
+			if a == b {
 				continue
 			}
-			btf := s.Registry.GetComponentPtr(b, TRANSFORM_COMPONENT).(*TransformComponent)
-			bcol := s.Registry.GetComponentPtr(b, BOX_COLLIDER_COMPONENT).(*BoxColliderComponent)
-			if CheckAABB(atf, btf, acol, bcol) {
-				s.events.Emit(COLLISION_EVENT, CollisionEvent{
+			if CheckAABB(ac.A, bc.A, ac.B, bc.B) {
+				eventbus.Publish(events, CollisionEvent{
 					a: a,
 					b: b,
 				})
-				s.Logger.Debug(fmt.Sprintf("COLLISION_EVENT fired entity=%d and entity=%d", a.GetID(), b.GetID()), nil)
+				s.Logger.Debug(fmt.Sprintf("CollisionEvent fired entity=%d and entity=%d", a.GetID(), b.GetID()), nil)
 			}
 		}
 	}
@@ -201,151 +263,54 @@ func CheckAABB(atf, btf *TransformComponent, acol, bcol *BoxColliderComponent) b
 	return aMinX < bMaxX && aMaxX > bMinX && aMinY < bMaxY && aMaxY > bMinY
 }
 
-// RENDER COLLISION SYSTEM ////////////////////////////////////////////////
-type RenderCollisionSystem struct {
-	*ecs.BaseSystem
-	renderer *sdl.Renderer
-	camera   *sdl.Rect
-}
-
-func NewRenderCollisionSystem(logger *logger.Logger, registry *ecs.Registry, renderer *sdl.Renderer, camera *sdl.Rect) *RenderCollisionSystem {
-	bs := bitset.NewBitset32()
-	bs.Set(int(TRANSFORM_COMPONENT))
-	bs.Set(int(BOX_COLLIDER_COMPONENT))
-	return &RenderCollisionSystem{
-		BaseSystem: ecs.NewBaseSystem("RenderCollisionSystem", logger, registry, bs),
-		renderer:   renderer,
-		camera:     camera,
-	}
-}
-
-func (s RenderCollisionSystem) GetName() string {
-	return s.Name
-}
-
-func (s *RenderCollisionSystem) Update(dt float32) {
-	for _, entity := range s.GetSystemEntities() {
-		tf := s.Registry.GetComponentPtr(entity, TRANSFORM_COMPONENT).(*TransformComponent)
-		col := s.Registry.GetComponentPtr(entity, BOX_COLLIDER_COMPONENT).(*BoxColliderComponent)
-		rect := sdl.Rect{
-			X: int32(tf.Position.X + col.Offset.X - float32(s.camera.X)),
-			Y: int32(tf.Position.Y + col.Offset.Y - float32(s.camera.Y)),
-			W: int32(tf.Scale.X * col.Width),
-			H: int32(tf.Scale.Y * col.Height),
-		}
-		s.renderer.SetDrawColor(255, 0, 0, 255)
-		s.renderer.DrawRect(&rect)
-	}
-}
-
 // DAMAGE SYSTEM ////////////////////////////////////////////////
 type DamageSystem struct {
 	*ecs.BaseSystem
-	events *eventbus.EventBus
 }
 
-func NewDamageSystem(logger *logger.Logger, registry *ecs.Registry, events *eventbus.EventBus) *DamageSystem {
-	bs := bitset.NewBitset32()
-	bs.Set(int(RIGIDBODY_COMPONENT))
-	bs.Set(int(TRANSFORM_COMPONENT))
-	return &DamageSystem{
-		BaseSystem: ecs.NewBaseSystem("DamageSystem", logger, registry, bs),
-		events:     events,
+func NewDamageSystem(logger *logger.Logger, registry *ecs.Registry) *DamageSystem {
+	s := &DamageSystem{
+		BaseSystem: ecs.NewBaseSystem("DamageSystem", logger, registry),
 	}
+	ecs.RequireComponent[RigidbodyComponent](s.BaseSystem)
+	ecs.RequireComponent[TransformComponent](s.BaseSystem)
+	return s
 }
 
 func (s DamageSystem) GetName() string {
 	return s.Name
 }
 
-func (s *DamageSystem) SubscribeToEvents() {
-	s.events.On(COLLISION_EVENT, s.OnCollision)
-}
-
-func (s *DamageSystem) OnCollision(payload any) {
-	p, ok := payload.(CollisionEvent)
-	if !ok {
-		s.Logger.Debug(fmt.Sprintf("DamageSystem:OnCollision invalid payload: %+v", payload), nil)
-	}
-	s.Registry.KillEntity(p.a)
-	s.Registry.KillEntity(p.b)
-	s.Logger.Debug(fmt.Sprintf("COLLISION_EVENT captured entity=%d and entity=%d", p.a.GetID(), p.b.GetID()), nil)
-}
-
-// KeyboardControl SYSTEM ////////////////////////////////////////////////
-type KeyboardControlSystem struct {
-	*ecs.BaseSystem
-	events *eventbus.EventBus
-}
-
-func NewKeyboardControlSystem(logger *logger.Logger, registry *ecs.Registry, events *eventbus.EventBus) *KeyboardControlSystem {
-	bs := bitset.NewBitset32()
-	bs.Set(int(SPRITE_COMPONENT))
-	bs.Set(int(RIGIDBODY_COMPONENT))
-	bs.Set(int(KEYBOARD_CONTROLLED_COMPONENT))
-	return &KeyboardControlSystem{
-		BaseSystem: ecs.NewBaseSystem("KeyboardControlSystem", logger, registry, bs),
-		events:     events,
-	}
-}
-
-func (s KeyboardControlSystem) GetName() string {
-	return s.Name
-}
-
-func (s *KeyboardControlSystem) SubscribeToEvents() {
-	s.events.On(KEYDOWN_EVENT, s.OnKeydown)
-}
-
-func (s *KeyboardControlSystem) Update(dt float32) {
+func (s *DamageSystem) Update(dt float32) {
 }
 
-func (s *KeyboardControlSystem) OnKeydown(payload any) {
-	p, ok := payload.(KeydownEvent)
-	if !ok {
+func (s *DamageSystem) SubscribeToEvents() {
+	events, err := ecs.GetResource[*eventbus.EventBus](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "DamageSystem: missing event bus", nil)
 		return
 	}
+	s.AddTeardown(eventbus.Subscribe(events, s.OnCollision).Unsubscribe)
+}
 
-	for _, entity := range s.GetSystemEntities() {
-		keyboard := s.Registry.GetComponentPtr(entity, KEYBOARD_CONTROLLED_COMPONENT).(*KeyboardControlledComponent)
-		sprite := s.Registry.GetComponentPtr(entity, SPRITE_COMPONENT).(*SpriteComponent)
-		rb := s.Registry.GetComponentPtr(entity, RIGIDBODY_COMPONENT).(*RigidbodyComponent)
-
-		switch p.Keysym.Sym {
-		case sdl.K_UP:
-			rb.Velocity = keyboard.upVelocity
-			sprite.SrcRect.Y = int32(sprite.Height * 0)
-		case sdl.K_RIGHT:
-			rb.Velocity = keyboard.rightVelocity
-			sprite.SrcRect.Y = int32(sprite.Height * 1)
-		case sdl.K_DOWN:
-			rb.Velocity = keyboard.downVelocity
-			sprite.SrcRect.Y = int32(sprite.Height * 2)
-		case sdl.K_LEFT:
-			rb.Velocity = keyboard.leftVelocity
-			sprite.SrcRect.Y = int32(sprite.Height * 3)
-		}
-	}
+func (s *DamageSystem) OnCollision(event CollisionEvent) {
+	s.Registry.Commands().Kill(event.a)
+	s.Registry.Commands().Kill(event.b)
+	s.Logger.Debug(fmt.Sprintf("CollisionEvent captured entity=%d and entity=%d", event.a.GetID(), event.b.GetID()), nil)
 }
 
 // CAMERA MOVEMENT SYSTEM ////////////////////////////////////////////////
 type CameraMovementSystem struct {
 	*ecs.BaseSystem
-	camera    *sdl.Rect
-	mapWidth  *float32
-	mapHeight *float32
 }
 
-func NewCameraMovementSystem(logger *logger.Logger, registry *ecs.Registry, camera *sdl.Rect, mapWidth, mapHeight *float32) *CameraMovementSystem {
-	bs := bitset.NewBitset32()
-	bs.Set(int(TRANSFORM_COMPONENT))
-	bs.Set(int(CAMERA_FOLLOW_COMPONENT))
-	return &CameraMovementSystem{
-		BaseSystem: ecs.NewBaseSystem("CameraMovementSystem", logger, registry, bs),
-		camera:     camera,
-		mapWidth:   mapWidth,
-		mapHeight:  mapHeight,
+func NewCameraMovementSystem(logger *logger.Logger, registry *ecs.Registry) *CameraMovementSystem {
+	s := &CameraMovementSystem{
+		BaseSystem: ecs.NewBaseSystem("CameraMovementSystem", logger, registry),
 	}
+	ecs.RequireComponent[TransformComponent](s.BaseSystem)
+	ecs.RequireComponent[CameraFollowComponent](s.BaseSystem)
+	return s
 }
 
 func (s CameraMovementSystem) GetName() string {
@@ -353,76 +318,95 @@ func (s CameraMovementSystem) GetName() string {
 }
 
 func (s *CameraMovementSystem) Update(dt float32) {
-	for _, entity := range s.GetSystemEntities() {
-		tf := s.Registry.GetComponentPtr(entity, TRANSFORM_COMPONENT).(*TransformComponent)
-		if tf.Position.X+float32(s.camera.W)/2 < *s.mapWidth {
-			s.camera.X = int32(tf.Position.X) - WIDTH/2
+	camera, err := ecs.GetResource[*engine.Rect](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "CameraMovementSystem: missing camera", nil)
+		return
+	}
+	bounds, err := ecs.GetResource[engine.MapBounds](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "CameraMovementSystem: missing map bounds", nil)
+		return
+	}
+	interpolation, err := ecs.GetResource[engine.Interpolation](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "CameraMovementSystem: missing interpolation", nil)
+		return
+	}
+
+	for _, tf := range ecs.Iter[TransformComponent](s.GetQuery()) {
+		position := tf.Interpolated(interpolation.Alpha)
+		if position.X+float32(camera.W)/2 < bounds.Width {
+			camera.X = int32(position.X) - camera.W/2
 		}
 
-		if tf.Position.Y+float32(s.camera.H)/2 < *s.mapHeight {
-			s.camera.Y = int32(tf.Position.Y) - HEIGHT/2
+		if position.Y+float32(camera.H)/2 < bounds.Height {
+			camera.Y = int32(position.Y) - camera.H/2
 		}
 
-		s.camera.X = utils.Clamp(s.camera.X, 0, s.camera.W)
-		s.camera.Y = utils.Clamp(s.camera.Y, 0, s.camera.H)
+		camera.X = utils.Clamp(camera.X, 0, camera.W)
+		camera.Y = utils.Clamp(camera.Y, 0, camera.H)
 	}
 }
 
 // TANK SPAWNER SYSTEM ////////////////////////////////////////////////
 type TankSpawnerSystem struct {
 	*ecs.BaseSystem
-	camera      *sdl.Rect
-	spawnTimers map[int]time.Time
+	spawnTimers map[ecs.Entity]time.Duration
 }
 
-func NewTankSpawnerSystem(logger *logger.Logger, registry *ecs.Registry, camera *sdl.Rect) *TankSpawnerSystem {
-	bs := bitset.NewBitset32()
-	bs.Set(int(TANK_SPAWNER_COMPONENT))
-	return &TankSpawnerSystem{
-		BaseSystem:  ecs.NewBaseSystem("TankSpawnerSystem", logger, registry, bs),
-		spawnTimers: make(map[int]time.Time),
-		camera:      camera,
+func NewTankSpawnerSystem(logger *logger.Logger, registry *ecs.Registry) *TankSpawnerSystem {
+	s := &TankSpawnerSystem{
+		BaseSystem:  ecs.NewBaseSystem("TankSpawnerSystem", logger, registry),
+		spawnTimers: make(map[ecs.Entity]time.Duration),
 	}
+	ecs.RequireComponent[TankSpawnerComponent](s.BaseSystem)
+	return s
 }
 
 func (s TankSpawnerSystem) GetName() string {
 	return s.Name
 }
 
+func (s *TankSpawnerSystem) RemoveEntityFromSystem(entity ecs.Entity) {
+	s.BaseSystem.RemoveEntityFromSystem(entity)
+	delete(s.spawnTimers, entity)
+}
+
 func (s *TankSpawnerSystem) Update(dt float32) {
+	camera, err := ecs.GetResource[*engine.Rect](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "TankSpawnerSystem: missing camera", nil)
+		return
+	}
+	gameTime, err := ecs.GetResource[clock.Clock](s.Registry)
+	if err != nil {
+		s.Logger.Error(err, "TankSpawnerSystem: missing clock", nil)
+		return
+	}
+
 	spawnTank := func(spawnPos vector.Vec2) {
-		newTank := s.Registry.CreateEntity()
-		s.Registry.AddComponent(newTank, SPRITE_COMPONENT, NewSpriteComponent(IMG_Tank, 32, 32, 1, false, 0, 0))
-		s.Registry.AddComponent(newTank, TRANSFORM_COMPONENT, TransformComponent{
-			Position: spawnPos,
-			Scale:    vector.Vec2{X: 1, Y: 1},
-			Rotation: 0,
-		})
 		velocityX := float32(rand.Intn(50)+25) * -1
-		s.Registry.AddComponent(newTank, RIGIDBODY_COMPONENT, RigidbodyComponent{
-			Velocity: vector.Vec2{X: velocityX, Y: 0},
-		})
-		s.Registry.AddComponent(newTank, BOX_COLLIDER_COMPONENT, BoxColliderComponent{
-			Width:  32,
-			Height: 32,
-			Offset: vector.NewZeroVec2(),
-		})
+		s.Registry.Commands().SpawnPrefab("tank",
+			ecs.Modify(func(tf *TransformComponent) { tf.Position = spawnPos }),
+			ecs.Override(RigidbodyComponent{Velocity: vector.Vec2{X: velocityX, Y: 0}}),
+		)
 	}
 
 	for _, entity := range s.GetSystemEntities() {
-		lastSpawnTime, exists := s.spawnTimers[entity.GetID()]
+		lastSpawnTime, exists := s.spawnTimers[entity]
 		spawnPos := vector.Vec2{
-			X: float32(s.camera.X + s.camera.W + int32(rand.Intn(50))),
-			Y: float32(rand.Intn(int(s.camera.H)) + int(s.camera.Y)),
+			X: float32(camera.X + camera.W + int32(rand.Intn(50))),
+			Y: float32(rand.Intn(int(camera.H)) + int(camera.Y)),
 		}
 		if !exists {
-			s.spawnTimers[entity.GetID()] = time.Now()
+			s.spawnTimers[entity] = gameTime.Now()
 			spawnTank(spawnPos)
 			continue
 		} else {
 			spawnBetween := float64(rand.Intn(5) + 2)
-			if time.Since(lastSpawnTime).Seconds() > spawnBetween {
-				s.spawnTimers[entity.GetID()] = time.Now()
+			if (gameTime.Now() - lastSpawnTime).Seconds() > spawnBetween {
+				s.spawnTimers[entity] = gameTime.Now()
 				spawnTank(spawnPos)
 			}
 		}
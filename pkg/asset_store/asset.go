@@ -0,0 +1,5 @@
+// Package asset_store loads and caches SDL textures. Builds with the headless
+// tag only get AssetID, so components can name assets without SDL.
+package asset_store
+
+type AssetID int
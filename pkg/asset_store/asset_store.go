@@ -1,3 +1,5 @@
+//go:build !headless
+
 package asset_store
 
 import (
@@ -5,8 +7,6 @@ import (
 	"github.com/veandco/go-sdl2/sdl"
 )
 
-type AssetID int
-
 type AssetStore struct {
 	textures map[AssetID]*sdl.Texture
 }
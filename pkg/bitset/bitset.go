@@ -1,6 +1,9 @@
 package bitset
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type Bitset interface {
 	Set(bit int)
@@ -93,3 +96,95 @@ func (b *Bitset64) validate(bit int) {
 func (b *Bitset64) String() string {
 	return fmt.Sprintf("%064b", b.value)
 }
+
+// BitsetN is a bitset of any width, backed by 64 bit words.
+type BitsetN struct {
+	size  int
+	words []uint64
+}
+
+func NewBitsetN(size int) *BitsetN {
+	return &BitsetN{
+		size:  size,
+		words: make([]uint64, (size+63)/64),
+	}
+}
+
+func (b *BitsetN) Size() int {
+	return b.size
+}
+
+func (b *BitsetN) Set(bit int) {
+	b.validate(bit)
+	b.words[bit/64] |= 1 << (bit % 64)
+}
+
+func (b *BitsetN) Clear(bit int) {
+	b.validate(bit)
+	b.words[bit/64] &^= 1 << (bit % 64)
+}
+
+func (b *BitsetN) IsSet(bit int) bool {
+	b.validate(bit)
+	return b.words[bit/64]&(1<<(bit%64)) != 0
+}
+
+func (b *BitsetN) Reset() {
+	for i := range b.words {
+		b.words[i] = 0
+	}
+}
+
+// Contains reports whether every bit set in other is also set in b.
+func (b *BitsetN) Contains(other *BitsetN) bool {
+	for i, word := range other.words {
+		if b.word(i)&word != word {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersects reports whether b and other have at least one bit in common.
+func (b *BitsetN) Intersects(other *BitsetN) bool {
+	for i, word := range other.words {
+		if b.word(i)&word != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *BitsetN) Empty() bool {
+	for _, word := range b.words {
+		if word != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *BitsetN) word(i int) uint64 {
+	if i >= len(b.words) {
+		return 0
+	}
+	return b.words[i]
+}
+
+func (b *BitsetN) validate(bit int) {
+	if bit < 0 || bit >= b.size {
+		panic("bit index out of range")
+	}
+}
+
+func (b *BitsetN) String() string {
+	var sb strings.Builder
+	for bit := b.size - 1; bit >= 0; bit-- {
+		if b.IsSet(bit) {
+			sb.WriteByte('1')
+		} else {
+			sb.WriteByte('0')
+		}
+	}
+	return sb.String()
+}
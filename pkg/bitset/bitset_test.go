@@ -72,6 +72,61 @@ func TestBitset64(t *testing.T) {
 	})
 }
 
+func TestBitsetN(t *testing.T) {
+	bs := NewBitsetN(100)
+
+	if bs.IsSet(0) {
+		t.Error("Expected bit 0 to be unset")
+	}
+
+	bs.Set(0)
+	bs.Set(64)
+	bs.Set(99)
+	if !bs.IsSet(0) || !bs.IsSet(64) || !bs.IsSet(99) {
+		t.Error("Expected bits 0, 64 and 99 to be set")
+	}
+
+	bs.Clear(64)
+	if bs.IsSet(64) {
+		t.Error("Expected bit 64 to be unset after Clear")
+	}
+
+	bs.Reset()
+	if !bs.Empty() {
+		t.Errorf("Expected all bits to be unset after Reset, got %s", bs)
+	}
+
+	expectPanic(t, func() {
+		bs.Set(100)
+	})
+}
+
+func TestBitsetNContainsIntersects(t *testing.T) {
+	signature := NewBitsetN(128)
+	signature.Set(1)
+	signature.Set(70)
+
+	required := NewBitsetN(128)
+	required.Set(70)
+	if !signature.Contains(required) {
+		t.Error("Expected signature to contain bit 70")
+	}
+	required.Set(100)
+	if signature.Contains(required) {
+		t.Error("Expected signature to not contain bit 100")
+	}
+
+	excluded := NewBitsetN(128)
+	excluded.Set(100)
+	if signature.Intersects(excluded) {
+		t.Error("Expected no common bits")
+	}
+	excluded.Set(1)
+	if !signature.Intersects(excluded) {
+		t.Error("Expected bit 1 in common")
+	}
+}
+
 func TestStringer(t *testing.T) {
 	bs32 := NewBitset32()
 	bs32.Set(0)
@@ -88,6 +143,13 @@ func TestStringer(t *testing.T) {
 	if bs64.String() != expectedString64 {
 		t.Errorf("Expected %s, got %s", expectedString64, bs64.String())
 	}
+
+	bsN := NewBitsetN(5)
+	bsN.Set(0)
+	bsN.Set(3)
+	if bsN.String() != "01001" {
+		t.Errorf("Expected 01001, got %s", bsN.String())
+	}
 }
 
 func expectPanic(t *testing.T, f func()) {
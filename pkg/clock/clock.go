@@ -0,0 +1,62 @@
+// Package clock abstracts reading time so simulations can run against a
+// clock that only moves when told to.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock reports the time elapsed since it started.
+type Clock interface {
+	Now() time.Duration
+	Sleep(d time.Duration)
+}
+
+// Real follows the wall clock.
+type Real struct {
+	start time.Time
+}
+
+func NewReal() *Real {
+	return &Real{start: time.Now()}
+}
+
+func (c *Real) Now() time.Duration {
+	return time.Since(c.start)
+}
+
+func (c *Real) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// Manual only moves when advanced, it is safe for concurrent use.
+type Manual struct {
+	mu  sync.RWMutex
+	now time.Duration
+}
+
+func NewManual() *Manual {
+	return &Manual{}
+}
+
+func (c *Manual) Now() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d, negative durations are ignored.
+func (c *Manual) Advance(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now += d
+}
+
+// Sleep advances the clock by d instead of blocking.
+func (c *Manual) Sleep(d time.Duration) {
+	c.Advance(d)
+}
@@ -0,0 +1,28 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManual(t *testing.T) {
+	c := NewManual()
+	if c.Now() != 0 {
+		t.Errorf("Expected a new clock to start at 0, got %v", c.Now())
+	}
+	c.Advance(16 * time.Millisecond)
+	c.Sleep(4 * time.Millisecond)
+	c.Advance(-time.Second)
+	if c.Now() != 20*time.Millisecond {
+		t.Errorf("Expected 20ms, got %v", c.Now())
+	}
+}
+
+func TestReal(t *testing.T) {
+	c := NewReal()
+	before := c.Now()
+	c.Sleep(time.Millisecond)
+	if elapsed := c.Now() - before; elapsed < time.Millisecond {
+		t.Errorf("Expected at least 1ms to pass, got %v", elapsed)
+	}
+}
@@ -0,0 +1,246 @@
+// Package config loads engine settings. Defaults are overridden in order by
+// a JSON file, environment variables and command-line flags.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/kubil6y/go_game_engine/pkg/logger"
+	"github.com/kubil6y/go_game_engine/pkg/timestep"
+)
+
+const (
+	// ENV_PREFIX starts the names of the environment variables read by Load,
+	// ENGINE_WIDTH sets width, ENGINE_LOG_LEVEL sets log-level.
+	ENV_PREFIX = "ENGINE_"
+	// CONFIG_FLAG names the flag, and with ENV_PREFIX the environment
+	// variable, holding the path of the config file.
+	CONFIG_FLAG = "config"
+
+	MAX_RESOLUTION = 16384
+	MAX_FPS        = 1000
+)
+
+var (
+	ErrInvalidConfig = errors.New("invalid config")
+)
+
+type Config struct {
+	Title      string `json:"title"`
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	Fullscreen bool   `json:"fullscreen"`
+	VSync      bool   `json:"vsync"`
+	FPS        int    `json:"fps"`
+	// MaxSteps limits the simulation steps a slow frame runs to catch up
+	MaxSteps int    `json:"max_steps"`
+	LogLevel string `json:"log_level"`
+	// Debug turns on debug overlays
+	Debug     bool   `json:"debug"`
+	AssetRoot string `json:"asset_root"`
+}
+
+func Default() Config {
+	return Config{
+		Title:      "Game",
+		Width:      800,
+		Height:     600,
+		Fullscreen: true,
+		VSync:      false,
+		FPS:        60,
+		MaxSteps:   timestep.DEFAULT_MAX_STEPS,
+		LogLevel:   "info",
+		Debug:      false,
+		AssetRoot:  "./assets",
+	}
+}
+
+// setting is a field of Config that can be set from a flag or an
+// environment variable.
+type setting struct {
+	name   string
+	usage  string
+	isBool bool
+	set    func(c *Config, value string) error
+}
+
+var settings = []setting{
+	{name: "title", usage: "window title", set: func(c *Config, value string) error {
+		c.Title = value
+		return nil
+	}},
+	{name: "width", usage: "logical window width", set: intSetter(func(c *Config) *int { return &c.Width })},
+	{name: "height", usage: "logical window height", set: intSetter(func(c *Config) *int { return &c.Height })},
+	{name: "fullscreen", usage: "cover the desktop instead of opening a window", isBool: true,
+		set: boolSetter(func(c *Config) *bool { return &c.Fullscreen })},
+	{name: "vsync", usage: "wait for the display refresh before presenting frames", isBool: true,
+		set: boolSetter(func(c *Config) *bool { return &c.VSync })},
+	{name: "fps", usage: "frames rendered per second", set: intSetter(func(c *Config) *int { return &c.FPS })},
+	{name: "max-steps", usage: "most simulation steps a frame runs to catch up",
+		set: intSetter(func(c *Config) *int { return &c.MaxSteps })},
+	{name: "log-level", usage: "debug, info, error, fatal or off", set: func(c *Config, value string) error {
+		c.LogLevel = value
+		return nil
+	}},
+	{name: "debug", usage: "show debug overlays", isBool: true,
+		set: boolSetter(func(c *Config) *bool { return &c.Debug })},
+	{name: "asset-root", usage: "directory assets are loaded from", set: func(c *Config, value string) error {
+		c.AssetRoot = value
+		return nil
+	}},
+}
+
+func intSetter(field func(c *Config) *int) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func boolSetter(field func(c *Config) *bool) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func envName(name string) string {
+	return ENV_PREFIX + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// Load returns defaults overridden by the config file, the environment and
+// the flags in args, then validates the result. The file is named by the
+// -config flag or the ENGINE_CONFIG variable, without one only the
+// environment and flags apply. fs may hold other flags of the program, they
+// are parsed along with the config flags. lookupEnv is usually os.LookupEnv.
+func Load(defaults Config, fs *flag.FlagSet, args []string, lookupEnv func(key string) (string, bool)) (Config, error) {
+	path := fs.String(CONFIG_FLAG, "", "path of a JSON config file")
+	// Flags are recorded and applied last so they win over the file and the
+	// environment
+	type flagValue struct {
+		setting setting
+		value   string
+	}
+	flagValues := make([]flagValue, 0)
+	for _, s := range settings {
+		record := func(value string) error {
+			flagValues = append(flagValues, flagValue{setting: s, value: value})
+			return nil
+		}
+		if s.isBool {
+			fs.BoolFunc(s.name, s.usage, record)
+		} else {
+			fs.Func(s.name, s.usage, record)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return defaults, err
+	}
+
+	c := defaults
+	if *path == "" {
+		*path, _ = lookupEnv(envName(CONFIG_FLAG))
+	}
+	if *path != "" {
+		if err := c.LoadFile(*path); err != nil {
+			return defaults, err
+		}
+	}
+	if err := c.ApplyEnv(lookupEnv); err != nil {
+		return defaults, err
+	}
+	for _, f := range flagValues {
+		if err := f.setting.set(&c, f.value); err != nil {
+			return defaults, fmt.Errorf("%w: -%s: %w", ErrInvalidConfig, f.setting.name, err)
+		}
+	}
+	if err := c.Validate(); err != nil {
+		return defaults, err
+	}
+	return c, nil
+}
+
+// LoadFile overrides c with the settings of the JSON file at path. Settings
+// missing from the file are kept, unknown ones are an error.
+func (c *Config) LoadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrInvalidConfig, path, err)
+	}
+	return nil
+}
+
+// ApplyEnv overrides c with the ENGINE_ environment variables that are set.
+func (c *Config) ApplyEnv(lookupEnv func(key string) (string, bool)) error {
+	for _, s := range settings {
+		value, exists := lookupEnv(envName(s.name))
+		if !exists {
+			continue
+		}
+		if err := s.set(c, value); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidConfig, envName(s.name), err)
+		}
+	}
+	return nil
+}
+
+// Validate checks every setting and reports all the invalid ones.
+func (c Config) Validate() error {
+	errs := make([]error, 0)
+	invalid := func(name, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: %s: %s", ErrInvalidConfig, name, fmt.Sprintf(format, args...)))
+	}
+	if strings.TrimSpace(c.Title) == "" {
+		invalid("title", "must not be empty")
+	}
+	if c.Width < 1 || c.Width > MAX_RESOLUTION {
+		invalid("width", "must be between 1 and %d, got %d", MAX_RESOLUTION, c.Width)
+	}
+	if c.Height < 1 || c.Height > MAX_RESOLUTION {
+		invalid("height", "must be between 1 and %d, got %d", MAX_RESOLUTION, c.Height)
+	}
+	if c.FPS < 1 || c.FPS > MAX_FPS {
+		invalid("fps", "must be between 1 and %d, got %d", MAX_FPS, c.FPS)
+	}
+	if c.MaxSteps < 1 {
+		invalid("max_steps", "must be at least 1, got %d", c.MaxSteps)
+	}
+	if _, err := logger.ParseLogLevel(c.LogLevel); err != nil {
+		invalid("log_level", "%s", err)
+	}
+	if info, err := os.Stat(c.AssetRoot); err != nil {
+		invalid("asset_root", "%s", err)
+	} else if !info.IsDir() {
+		invalid("asset_root", "%q is not a directory", c.AssetRoot)
+	}
+	return errors.Join(errs...)
+}
+
+// Level returns the parsed log level, LEVEL_INFO when it is invalid.
+func (c Config) Level() logger.LogLevel {
+	level, err := logger.ParseLogLevel(c.LogLevel)
+	if err != nil {
+		return logger.LEVEL_INFO
+	}
+	return level
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testDefaults(t *testing.T) Config {
+	c := Default()
+	c.AssetRoot = t.TempDir()
+	return c
+}
+
+func env(vars map[string]string) func(key string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, exists := vars[key]
+		return value, exists
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	defaults := testDefaults(t)
+	path := filepath.Join(t.TempDir(), "config.json")
+	file := `{"title": "From file", "width": 1024, "height": 768, "fps": 30}`
+	if err := os.WriteFile(path, []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	headless := fs.Bool("headless", false, "")
+	c, err := Load(defaults, fs,
+		[]string{"-config", path, "-fps", "120", "-fullscreen=false", "-debug", "-headless"},
+		env(map[string]string{"ENGINE_HEIGHT": "720", "ENGINE_FPS": "90", "ENGINE_LOG_LEVEL": "debug", "ENGINE_MAX_STEPS": "8"}),
+	)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	expected := defaults
+	expected.Title = "From file"
+	expected.Width = 1024
+	expected.Height = 720
+	expected.FPS = 120
+	expected.MaxSteps = 8
+	expected.Fullscreen = false
+	expected.Debug = true
+	expected.LogLevel = "debug"
+	if c != expected {
+		t.Errorf("Expected %+v, got %+v", expected, c)
+	}
+	if !*headless {
+		t.Error("Expected other flags of the flag set to be parsed")
+	}
+}
+
+func TestLoadConfigPathFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"vsync": true}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(testDefaults(t), flag.NewFlagSet("test", flag.ContinueOnError), nil,
+		env(map[string]string{"ENGINE_CONFIG": path}))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !c.VSync {
+		t.Error("Expected the file named by ENGINE_CONFIG to be loaded")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"widht": 1024}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		contains string
+	}{
+		{"unknown file field", []string{"-config", path}, nil, "widht"},
+		{"bad env value", nil, map[string]string{"ENGINE_WIDTH": "wide"}, "ENGINE_WIDTH"},
+		{"bad flag value", []string{"-fps", "fast"}, nil, "-fps"},
+		{"invalid value", []string{"-log-level", "verbose"}, nil, "log_level"},
+	}
+	for _, tc := range cases {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		_, err := Load(testDefaults(t), fs, tc.args, env(tc.env))
+		if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), tc.contains) {
+			t.Errorf("%s: expected an invalid config error mentioning %q, got %v", tc.name, tc.contains, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := testDefaults(t)
+	if err := c.Validate(); err != nil {
+		t.Errorf("Expected defaults to be valid, got %v", err)
+	}
+
+	c.Title = " "
+	c.Width = 0
+	c.Height = MAX_RESOLUTION + 1
+	c.FPS = -1
+	c.MaxSteps = 0
+	c.LogLevel = "loud"
+	c.AssetRoot = filepath.Join(c.AssetRoot, "missing")
+	err := c.Validate()
+	for _, name := range []string{"title", "width", "height", "fps", "max_steps", "log_level", "asset_root"} {
+		if err == nil || !strings.Contains(err.Error(), name+":") {
+			t.Errorf("Expected an error for %s, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+type path struct {
+	Points []position
+}
+
+func (p path) Clone() path {
+	return path{Points: slices.Clone(p.Points)}
+}
+
+type inventory struct {
+	Items map[string]int
+}
+
+func (i *inventory) Clone() inventory {
+	items := make(map[string]int, len(i.Items))
+	for name, count := range i.Items {
+		items[name] = count
+	}
+	return inventory{Items: items}
+}
+
+type shallow struct {
+	Points []position
+}
+
+func TestClone(t *testing.T) {
+	r := newTestRegistry()
+	q, _ := NewQuery(r, With[position](), With[path]())
+	root := r.CreateEntity()
+	e := r.CreateEntity()
+	child := r.CreateEntity()
+	Add(r, e, position{X: 1})
+	Add(r, e, path{Points: []position{{X: 1}}})
+	Add(r, e, inventory{Items: map[string]int{"ammo": 3}})
+	Add(r, e, shallow{Points: []position{{X: 1}}})
+	Add(r, child, velocity{})
+	SetParent(r, e, root)
+	SetParent(r, child, e)
+	r.SetName(e, "original")
+	r.AddTag(e, "enemy")
+	r.Update()
+
+	clone, err := r.Clone(e)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if q.Has(clone) {
+		t.Error("Expected the clone to join queries on Update")
+	}
+	r.Update()
+	if !q.Has(clone) {
+		t.Error("Expected the clone to join queries on Update")
+	}
+
+	p, _ := Get[path](r, clone)
+	p.Points[0].X = 2
+	inv, _ := Get[inventory](r, clone)
+	inv.Items["ammo"] = 0
+	s, _ := Get[shallow](r, clone)
+	s.Points[0].X = 2
+	if p, _ := Get[path](r, e); p.Points[0].X != 1 {
+		t.Error("Expected value Clone method to deep copy")
+	}
+	if inv, _ := Get[inventory](r, e); inv.Items["ammo"] != 3 {
+		t.Error("Expected pointer Clone method to deep copy")
+	}
+	if s, _ := Get[shallow](r, e); s.Points[0].X != 2 {
+		t.Error("Expected components without Clone to be copied by value")
+	}
+
+	if r.GetName(clone) != "" || !r.HasTag(clone, "enemy") {
+		t.Errorf("Expected the clone to get tags but no name, got %q %v", r.GetName(clone), r.GetTags(clone))
+	}
+	if parent, _ := GetParent(r, clone); parent != root {
+		t.Errorf("Expected the clone to be a sibling, got parent %s", parent)
+	}
+	if children := GetChildren(r, clone); len(children) != 1 || children[0] == child {
+		t.Errorf("Expected the children to be cloned, got %v", children)
+	}
+}
+
+func TestCommandsClone(t *testing.T) {
+	r := newTestRegistry()
+	e := r.CreateEntity()
+	Add(r, e, position{X: 5})
+	cmds := r.Commands()
+	clone := cmds.Clone(e)
+	AddDeferred(cmds, clone, velocity{})
+	r.Update()
+
+	q, _ := NewQuery(r, With[position](), With[velocity]())
+	if q.Len() != 1 || q.Entities()[0] == e {
+		t.Errorf("Expected the clone to get the deferred component, got %v", q.Entities())
+	}
+}
+
+func TestCommandsCloneSpawnedPlaceholder(t *testing.T) {
+	r := newTestRegistry()
+	cmds := NewCommands()
+	spawned := cmds.Spawn()
+	AddDeferred(cmds, spawned, position{X: 7})
+	clone := cmds.Clone(spawned)
+	AddDeferred(cmds, clone, velocity{})
+	if err := r.Apply(cmds); err != nil {
+		t.Fatalf("Unexpected error applying commands: %v", err)
+	}
+	r.Update()
+
+	q, _ := NewQuery(r, With[position](), With[velocity]())
+	if q.Len() != 1 {
+		t.Fatalf("Expected the clone of the spawned entity to match, got %v", q.Entities())
+	}
+	if p, _ := Get[position](r, q.Entities()[0]); p.X != 7 {
+		t.Errorf("Expected the clone to copy the spawned entity, got %+v", p)
+	}
+}
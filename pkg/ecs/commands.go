@@ -0,0 +1,154 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	ErrStalePlaceholder = errors.New("placeholder of another buffer or of an applied one")
+)
+
+type command func(r *Registry, spawned *placeholders) error
+
+// placeholders maps the placeholders reserved in a buffer to the entities
+// created for them by one Apply.
+type placeholders struct {
+	epoch    uint32
+	entities []Entity
+}
+
+// lastEpoch numbers the placeholder sets of every buffer, a placeholder
+// carries its epoch in its generation.
+var lastEpoch atomic.Uint32
+
+func nextEpoch() uint32 {
+	return lastEpoch.Add(1)
+}
+
+// Commands records structural changes (creating and killing entities, adding
+// and removing components) so systems and event handlers can request them
+// while iterating. They are applied in order by Registry.Apply; the registry
+// applies its own buffer, Registry.Commands, at the start of Update.
+//
+// Commands is safe for concurrent use.
+type Commands struct {
+	mu       sync.Mutex
+	commands []command
+	reserved int
+	epoch    uint32
+}
+
+func NewCommands() *Commands {
+	return &Commands{
+		commands: make([]command, 0),
+		epoch:    nextEpoch(),
+	}
+}
+
+func (c *Commands) push(cmd command) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.commands = append(c.commands, cmd)
+}
+
+// Spawn reserves an entity that is created when the buffer is applied. The
+// returned handle is a placeholder: it can be passed to later commands of the
+// same buffer until the buffer is applied, but not to the registry.
+func (c *Commands) Spawn() Entity {
+	return c.reserve(func(r *Registry, spawned *placeholders) (Entity, error) {
+		return r.CreateEntity(), nil
+	})
+}
+
+// reserve records create and returns a placeholder for the entity it creates.
+// create gets the entities spawned so far to resolve placeholders it uses.
+func (c *Commands) reserve(create func(r *Registry, spawned *placeholders) (Entity, error)) Entity {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	index := c.reserved
+	c.reserved++
+	c.commands = append(c.commands, func(r *Registry, spawned *placeholders) error {
+		entity, err := create(r, spawned)
+		spawned.entities[index] = entity
+		return err
+	})
+	return Entity{ID: -(index + 1), Generation: c.epoch}
+}
+
+func (c *Commands) Kill(entity Entity) {
+	c.push(func(r *Registry, spawned *placeholders) error {
+		entity, err := resolve(entity, spawned)
+		if err != nil {
+			return err
+		}
+		r.KillEntity(entity)
+		return nil
+	})
+}
+
+func (c *Commands) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.commands)
+}
+
+// AddDeferred records adding component to entity.
+func AddDeferred[T any](c *Commands, entity Entity, component T) {
+	c.push(func(r *Registry, spawned *placeholders) error {
+		entity, err := resolve(entity, spawned)
+		if err != nil {
+			return err
+		}
+		return Add(r, entity, component)
+	})
+}
+
+// RemoveDeferred records removing the T component of entity.
+func RemoveDeferred[T any](c *Commands, entity Entity) {
+	c.push(func(r *Registry, spawned *placeholders) error {
+		entity, err := resolve(entity, spawned)
+		if err != nil {
+			return err
+		}
+		return Remove[T](r, entity)
+	})
+}
+
+// resolve maps a placeholder returned by Commands.Spawn to the entity created
+// for it. Placeholders of other buffers or of earlier Applies are rejected,
+// their indices would name unrelated entities.
+func resolve(entity Entity, spawned *placeholders) (Entity, error) {
+	if entity.ID >= 0 {
+		return entity, nil
+	}
+	index := -entity.ID - 1
+	if entity.Generation != spawned.epoch || index >= len(spawned.entities) {
+		return entity, fmt.Errorf("%w: %s", ErrStalePlaceholder, entity)
+	}
+	return spawned.entities[index], nil
+}
+
+func (r *Registry) Commands() *Commands {
+	return r.commands
+}
+
+// Apply runs the commands recorded in c and empties it. Failing commands are
+// skipped and their errors returned together.
+func (r *Registry) Apply(c *Commands) error {
+	c.mu.Lock()
+	commands, reserved, epoch := c.commands, c.reserved, c.epoch
+	c.commands, c.reserved, c.epoch = make([]command, 0), 0, nextEpoch()
+	c.mu.Unlock()
+
+	spawned := &placeholders{epoch: epoch, entities: make([]Entity, reserved)}
+	var errs []error
+	for _, cmd := range commands {
+		if err := cmd(r, spawned); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,125 @@
+package ecs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCommandsAreDeferredUntilUpdate(t *testing.T) {
+	r := newTestRegistry()
+	e := r.CreateEntity()
+	Add(r, e, position{})
+	r.Update()
+
+	cmds := r.Commands()
+	cmds.Kill(e)
+	RemoveDeferred[position](cmds, e)
+	if !Has[position](r, e) {
+		t.Fatal("Expected RemoveDeferred to not touch the registry before Update")
+	}
+	if cmds.Len() != 2 {
+		t.Errorf("Expected 2 recorded commands, got %d", cmds.Len())
+	}
+
+	r.Update()
+	if r.IsAlive(e) {
+		t.Errorf("Expected %s to be killed on Update", e)
+	}
+	if cmds.Len() != 0 {
+		t.Errorf("Expected the buffer to be emptied, got %d commands", cmds.Len())
+	}
+}
+
+func TestCommandsSpawnPlaceholder(t *testing.T) {
+	r := newTestRegistry()
+	q, _ := NewQuery(r, With[position](), With[velocity]())
+
+	cmds := NewCommands()
+	a := cmds.Spawn()
+	b := cmds.Spawn()
+	AddDeferred(cmds, a, position{X: 1})
+	AddDeferred(cmds, b, position{X: 2})
+	AddDeferred(cmds, a, velocity{})
+	if r.IsAlive(a) {
+		t.Errorf("Expected placeholder %s to not be alive", a)
+	}
+
+	if err := r.Apply(cmds); err != nil {
+		t.Fatalf("Unexpected error applying commands: %v", err)
+	}
+	r.Update()
+	if q.Len() != 1 {
+		t.Fatalf("Expected the first spawned entity to match, got %v", q.Entities())
+	}
+	p, _ := Get[position](r, q.Entities()[0])
+	if p.X != 1 {
+		t.Errorf("Expected components to go to the entity reserved for them, got %+v", p)
+	}
+}
+
+func TestCommandsReportErrors(t *testing.T) {
+	r := newTestRegistry()
+	e := r.CreateEntity()
+	r.KillEntity(e)
+	r.Update()
+
+	cmds := NewCommands()
+	stale := cmds.Spawn()
+	r.Apply(cmds)
+
+	AddDeferred(cmds, e, position{})
+	AddDeferred(cmds, stale, position{})
+	spawned := cmds.Spawn()
+	AddDeferred(cmds, spawned, velocity{})
+	err := r.Apply(cmds)
+	if !errors.Is(err, ErrEntityNotAlive) {
+		t.Errorf("Expected ErrEntityNotAlive, got %v", err)
+	}
+	if !errors.Is(err, ErrStalePlaceholder) {
+		t.Errorf("Expected ErrStalePlaceholder for a placeholder of an earlier Apply, got %v", err)
+	}
+	vel, _ := GetPool[velocity](r)
+	if vel.Len() != 1 {
+		t.Errorf("Expected valid commands to still be applied, got %d velocities", vel.Len())
+	}
+	pos, _ := GetPool[position](r)
+	if pos.Len() != 0 {
+		t.Errorf("Expected the stale placeholder to not resolve to the new entity, got %d positions", pos.Len())
+	}
+}
+
+func TestCommandsRejectPlaceholdersOfOtherBuffers(t *testing.T) {
+	r := newTestRegistry()
+	a, b := NewCommands(), NewCommands()
+	placeholder := a.Spawn()
+	b.Spawn()
+	AddDeferred(b, placeholder, position{})
+	if err := r.Apply(b); !errors.Is(err, ErrStalePlaceholder) {
+		t.Errorf("Expected ErrStalePlaceholder, got %v", err)
+	}
+	pos, _ := GetPool[position](r)
+	if pos.Len() != 0 {
+		t.Errorf("Expected the placeholder of another buffer to not resolve, got %d positions", pos.Len())
+	}
+}
+
+func TestCommandsFromParallelSystems(t *testing.T) {
+	r := newTestRegistry()
+	r.SetWorkers(4)
+	for i := 0; i < 4; i++ {
+		spawner := newFuncSystem(r, "spawner", func(dt float32) {
+			for j := 0; j < 100; j++ {
+				AddDeferred(r.Commands(), r.Commands().Spawn(), position{})
+			}
+		})
+		r.AddSystem(SystemTypeID(i), spawner, Reads[velocity]())
+	}
+
+	r.RunStage(StageUpdate, 0)
+	r.Update()
+
+	pool, _ := GetPool[position](r)
+	if pool.Len() != 400 {
+		t.Errorf("Expected 400 spawned entities, got %d", pool.Len())
+	}
+}
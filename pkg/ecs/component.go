@@ -0,0 +1,135 @@
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ComponentID returns the id assigned to the component type T, registering
+// T with the registry on first use.
+func ComponentID[T any](r *Registry) (ComponentTypeID, error) {
+	if id := typeID[T](r); id != -1 {
+		return ComponentTypeID(id), nil
+	}
+	var zero T
+	id, err := r.componentTypes.Register(zero)
+	if err != nil {
+		return -1, err
+	}
+	return ComponentTypeID(id), nil
+}
+
+// Add adds component to entity, replacing the T component it already has.
+// OnAdd hooks run for new components and OnSet hooks run in both cases.
+// Inside a parallel batch only existing components can be replaced, adding
+// one fails with ErrParallelChange.
+func Add[T any](r *Registry, entity Entity, component T) error {
+	if !r.IsAlive(entity) {
+		return ErrEntityNotAlive
+	}
+	componentID, err := ComponentID[T](r)
+	if err != nil {
+		return err
+	}
+	added := !r.hasComponent(entity, componentID)
+	if added && r.parallel.Load() {
+		return fmt.Errorf("%w: add %T to %s", ErrParallelChange, component, entity)
+	}
+	pool := poolOf[T](r, componentID)
+	pool.Set(entity.GetID(), component)
+	pool.stamp(entity.GetID(), r.tick, added)
+	if added {
+		r.entityComponentSignatures[entity.GetID()].Set(int(componentID))
+		r.queueSignatureChange(entity)
+	}
+
+	hooks := r.hooksOf(componentID)
+	if added {
+		runHooks(r, hooks.onAdd, entity)
+	}
+	runHooks(r, hooks.onSet, entity)
+	return nil
+}
+
+func Remove[T any](r *Registry, entity Entity) error {
+	if !r.IsAlive(entity) {
+		return ErrEntityNotAlive
+	}
+	componentID, err := ComponentID[T](r)
+	if err != nil {
+		return err
+	}
+	if r.parallel.Load() {
+		return fmt.Errorf("%w: remove %T from %s", ErrParallelChange, *new(T), entity)
+	}
+	r.removeComponent(entity, componentID)
+	return nil
+}
+
+func Has[T any](r *Registry, entity Entity) bool {
+	if !r.IsAlive(entity) {
+		return false
+	}
+	componentID := typeID[T](r)
+	if componentID == -1 {
+		return false
+	}
+	return r.hasComponent(entity, ComponentTypeID(componentID))
+}
+
+// Get returns a pointer to the T component of entity. The pointer stays valid
+// until the next component of type T is added to or removed from the registry.
+func Get[T any](r *Registry, entity Entity) (*T, error) {
+	if !r.IsAlive(entity) {
+		return nil, ErrEntityNotAlive
+	}
+	component := fetch(typedPool[T](r), entity)
+	if component == nil {
+		return nil, ErrComponentNotFound
+	}
+	return component, nil
+}
+
+// GetPool returns the packed storage of T components for fast iteration.
+func GetPool[T any](r *Registry) (*Pool[T], error) {
+	componentID, err := ComponentID[T](r)
+	if err != nil {
+		return nil, err
+	}
+	return poolOf[T](r, componentID), nil
+}
+
+func poolOf[T any](r *Registry, componentID ComponentTypeID) *Pool[T] {
+	for int(componentID) >= len(r.componentPools) {
+		r.componentPools = append(r.componentPools, nil)
+	}
+	if r.componentPools[componentID] == nil {
+		r.componentPools[componentID] = NewPool[T](0)
+	}
+	return r.componentPools[componentID].(*Pool[T])
+}
+
+// typeID returns the id of the component type T, or -1 when T was never
+// registered. It sits on the Get and Has hot path so it avoids reflect.TypeOf
+// and the registry lock.
+func typeID[T any](r *Registry) int {
+	return r.componentTypes.lookup(reflect.TypeFor[T]())
+}
+
+// typedPool returns nil when no T component was ever added.
+func typedPool[T any](r *Registry) *Pool[T] {
+	componentID := typeID[T](r)
+	if componentID == -1 {
+		return nil
+	}
+	pool, _ := r.getPool(ComponentTypeID(componentID)).(*Pool[T])
+	return pool
+}
+
+func fetch[T any](pool *Pool[T], entity Entity) *T {
+	if pool == nil {
+		return nil
+	}
+	component, _ := pool.Get(entity.GetID())
+	return component
+}
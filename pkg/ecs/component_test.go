@@ -0,0 +1,170 @@
+package ecs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubil6y/go_game_engine/pkg/logger"
+)
+
+type position struct {
+	X, Y float32
+}
+
+type velocity struct {
+	X, Y float32
+}
+
+func newTestRegistry() *Registry {
+	return NewRegistry(32, logger.New(logger.WithLogLevel(logger.LEVEL_OFF)))
+}
+
+func TestAddGetComponent(t *testing.T) {
+	r := newTestRegistry()
+	e := r.CreateEntity()
+	if err := Add(r, e, position{X: 1, Y: 2}); err != nil {
+		t.Fatalf("Unexpected error during add: %v", err)
+	}
+	p, err := Get[position](r, e)
+	if err != nil {
+		t.Fatalf("Unexpected error during get: %v", err)
+	}
+	if p.X != 1 || p.Y != 2 {
+		t.Errorf("Expected position {1 2}, got %+v", *p)
+	}
+
+	p.X = 10
+	p, _ = Get[position](r, e)
+	if p.X != 10 {
+		t.Errorf("Expected Get to return a pointer into the pool, got X=%v", p.X)
+	}
+}
+
+func TestGetMissingComponent(t *testing.T) {
+	r := newTestRegistry()
+	e := r.CreateEntity()
+	if _, err := Get[velocity](r, e); err != ErrComponentNotFound {
+		t.Errorf("Expected ErrComponentNotFound for unregistered type, got %v", err)
+	}
+	Add(r, e, position{})
+	other := r.CreateEntity()
+	if _, err := Get[position](r, other); err != ErrComponentNotFound {
+		t.Errorf("Expected ErrComponentNotFound for entity without component, got %v", err)
+	}
+}
+
+func TestHasRemoveComponent(t *testing.T) {
+	r := newTestRegistry()
+	e := r.CreateEntity()
+	if Has[position](r, e) {
+		t.Error("Expected entity to not have position")
+	}
+	Add(r, e, position{})
+	if !Has[position](r, e) {
+		t.Error("Expected entity to have position")
+	}
+	if err := Remove[position](r, e); err != nil {
+		t.Fatalf("Unexpected error during remove: %v", err)
+	}
+	if Has[position](r, e) {
+		t.Error("Expected entity to not have position after Remove")
+	}
+}
+
+func TestComponentIDsAreStable(t *testing.T) {
+	r := newTestRegistry()
+	posID, _ := ComponentID[position](r)
+	velID, _ := ComponentID[velocity](r)
+	if posID == velID {
+		t.Errorf("Expected different ids for different types, got %d", posID)
+	}
+	again, _ := ComponentID[position](r)
+	if posID != again {
+		t.Errorf("Expected the same id for the same type, got %d and %d", posID, again)
+	}
+}
+
+func TestComponentLimit(t *testing.T) {
+	r := NewRegistry(1, logger.New(logger.WithLogLevel(logger.LEVEL_OFF)))
+	e := r.CreateEntity()
+	if err := Add(r, e, position{}); err != nil {
+		t.Fatalf("Unexpected error during add: %v", err)
+	}
+	if err := Add(r, e, velocity{}); err != ErrMaxItemsExceeded {
+		t.Errorf("Expected ErrMaxItemsExceeded, got %v", err)
+	}
+}
+
+func TestKillEntityRemovesComponents(t *testing.T) {
+	r := newTestRegistry()
+	e := r.CreateEntity()
+	Add(r, e, position{})
+	r.KillEntity(e)
+	r.Update()
+
+	pool, _ := GetPool[position](r)
+	if pool.Len() != 0 {
+		t.Errorf("Expected killed entity's component to be removed, pool has %d", pool.Len())
+	}
+}
+
+// registerDummyComponents fills the first n component ids with distinct types.
+func registerDummyComponents(t *testing.T, r *Registry, n int) {
+	for i := 1; i <= n; i++ {
+		dummy := reflect.New(reflect.ArrayOf(i, reflect.TypeOf(byte(0)))).Elem().Interface()
+		if _, err := r.componentTypes.Register(dummy); err != nil {
+			t.Fatalf("Unexpected error registering dummy component %d: %v", i, err)
+		}
+	}
+}
+
+func TestMoreThan32Components(t *testing.T) {
+	r := NewRegistry(100, logger.New(logger.WithLogLevel(logger.LEVEL_OFF)))
+	registerDummyComponents(t, r, 70)
+
+	e := r.CreateEntity()
+	if err := Add(r, e, position{}); err != nil {
+		t.Fatalf("Unexpected error adding component #71: %v", err)
+	}
+	if id, _ := ComponentID[position](r); id != 70 {
+		t.Errorf("Expected position to get id 70, got %d", id)
+	}
+	q, _ := NewQuery(r, With[position]())
+	r.Update()
+	if !q.Has(e) {
+		t.Errorf("Expected %s to match a query on component id 70", e)
+	}
+}
+
+func TestTooManyComponentTypes(t *testing.T) {
+	r := NewRegistry(40, logger.New(logger.WithLogLevel(logger.LEVEL_OFF)))
+	registerDummyComponents(t, r, 40)
+
+	e := r.CreateEntity()
+	if err := Add(r, e, position{}); err != ErrMaxItemsExceeded {
+		t.Errorf("Expected ErrMaxItemsExceeded, got %v", err)
+	}
+	if _, err := NewQuery(r, With[velocity]()); err != ErrMaxItemsExceeded {
+		t.Errorf("Expected ErrMaxItemsExceeded from NewQuery, got %v", err)
+	}
+}
+
+func TestAddAtComponentLimit(t *testing.T) {
+	r := NewRegistry(2, logger.New(logger.WithLogLevel(logger.LEVEL_OFF)))
+	e := r.CreateEntity()
+	if err := Add(r, e, position{}); err != nil {
+		t.Fatalf("Unexpected error adding position: %v", err)
+	}
+	if err := Add(r, e, velocity{}); err != nil {
+		t.Fatalf("Unexpected error adding velocity: %v", err)
+	}
+	if err := Add(r, e, position{X: 1}); err != nil {
+		t.Errorf("Expected Add of a registered type to work at the limit, got %v", err)
+	}
+	if _, err := Get[velocity](r, e); err != nil {
+		t.Errorf("Expected Get of a registered type to work at the limit, got %v", err)
+	}
+	if err := Add(r, e, "third"); err != ErrMaxItemsExceeded {
+		t.Errorf("Expected ErrMaxItemsExceeded for a third type, got %v", err)
+	}
+}
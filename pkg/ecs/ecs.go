@@ -2,106 +2,192 @@ package ecs
 
 import (
 	"container/list"
+	"errors"
 	"fmt"
-	"math"
-	"reflect"
+	"sync/atomic"
 
-	"github.com/kubil6y/go_game_engine/internal/utils"
 	"github.com/kubil6y/go_game_engine/pkg/bitset"
 	"github.com/kubil6y/go_game_engine/pkg/logger"
+	"github.com/kubil6y/go_game_engine/pkg/set"
+)
+
+var (
+	ErrComponentNotFound = errors.New("component not found")
+	ErrEntityNotAlive    = errors.New("entity is not alive")
+	// ErrParallelChange is returned by Add and Remove when they would change
+	// the components of an entity while systems run concurrently.
+	ErrParallelChange = errors.New("structural change inside a parallel batch, record it with Commands")
 )
 
 type SystemTypeID int
 type ComponentTypeID int
 
+// Entity is a handle to an entity. IDs are reused after an entity is killed,
+// Generation tells the handles of the old and the new entity apart.
 type Entity struct {
-	ID int
-}
-
-type Component interface {
-	GetID() int
-	fmt.Stringer
+	ID         int
+	Generation uint32
 }
 
-func NewEntity(id int) Entity {
-	return Entity{ID: id}
+func NewEntity(id int, generation uint32) Entity {
+	return Entity{ID: id, Generation: generation}
 }
 
 func (e Entity) GetID() int {
 	return e.ID
 }
 
+func (e Entity) String() string {
+	return fmt.Sprintf("Entity{%d:%d}", e.ID, e.Generation)
+}
+
 type Registry struct {
-	numEntities int
+	numEntities       int
+	maxComponentCount int
 	// [index = entity id]
-	entityComponentSignatures []bitset.Bitset32
-	// [index = component id] [index = entity id]
-	componentPools     map[ComponentTypeID]any
-	systems            map[SystemTypeID]System
-	entitiesToBeAdded  []Entity
-	entitiesToBeKilled []Entity
-	freeIDs            *list.List
-	logger             *logger.Logger
-}
-
-func NewRegistry(maxComponentCount int, logger *logger.Logger) *Registry {
-	return &Registry{
+	entityComponentSignatures []*bitset.BitsetN
+	// [index = entity id]
+	entityGenerations []uint32
+	// [index = entity id] false for ids never used and ids on freeIDs
+	entityAlive []bool
+	// [index = component id]
+	componentPools []componentPool
+	componentTypes *TypeRegistry
+	systems        map[SystemTypeID]System
+	queries        map[queryKey]*Query
+	schedule       map[SystemTypeID]*scheduledSystem
+	// [key = stage] [value = system ids in run order]
+	stages map[Stage][]SystemTypeID
+	// [key = stage] [value = groups of systems that can run concurrently]
+	batches        map[Stage][][]SystemTypeID
+	scheduleDirty  bool
+	disabledGroups set.Set[string]
+	workers        int
+	// set while the systems of a batch run concurrently
+	parallel atomic.Bool
+	// entities whose signature changed since the last Update
+	entitiesToBeUpdated []Entity
+	pendingUpdates      set.Set[Entity]
+	entitiesToBeKilled  []Entity
+	commands            *Commands
+	resources           *resources
+	codecs              *codecs
+	prefabs             map[string]*prefab
+	labels              *labels
+	hooks               map[ComponentTypeID]*componentHooks
+	killHooks           []registeredHook
+	lastHookID          uint64
+	// incremented by every Update, see Added and Changed
+	tick    uint64
+	freeIDs *list.List
+	logger  *logger.Logger
+}
+
+type RegistryOption func(r *Registry) *Registry
+
+func NewRegistry(maxComponentCount int, logger *logger.Logger, opts ...RegistryOption) *Registry {
+	r := &Registry{
 		numEntities:               0,
-		entityComponentSignatures: make([]bitset.Bitset32, 10),
-		componentPools:            make(map[ComponentTypeID]any),
+		maxComponentCount:         maxComponentCount,
+		entityComponentSignatures: make([]*bitset.BitsetN, 10),
+		entityGenerations:         make([]uint32, 10),
+		entityAlive:               make([]bool, 10),
+		componentPools:            make([]componentPool, 0, maxComponentCount),
+		componentTypes:            NewTypeRegistry(maxComponentCount),
 		systems:                   make(map[SystemTypeID]System),
-		entitiesToBeAdded:         make([]Entity, 0),
+		queries:                   make(map[queryKey]*Query),
+		schedule:                  make(map[SystemTypeID]*scheduledSystem),
+		stages:                    make(map[Stage][]SystemTypeID),
+		batches:                   make(map[Stage][][]SystemTypeID),
+		disabledGroups:            set.New[string](),
+		workers:                   1,
+		entitiesToBeUpdated:       make([]Entity, 0),
+		pendingUpdates:            set.New[Entity](),
 		entitiesToBeKilled:        make([]Entity, 0),
+		commands:                  NewCommands(),
+		resources:                 newResources(),
+		codecs:                    newCodecs(),
+		prefabs:                   make(map[string]*prefab),
+		labels:                    newLabels(),
+		hooks:                     make(map[ComponentTypeID]*componentHooks),
+		killHooks:                 make([]registeredHook, 0),
+		tick:                      1,
 		freeIDs:                   list.New(),
 		logger:                    logger,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // ENTITY MANAGEMENT ////////////////////
+// CreateEntity must not be called while systems run concurrently, they should
+// use Commands.Spawn instead.
 func (r *Registry) CreateEntity() Entity {
+	if r.parallel.Load() {
+		panic("ecs: CreateEntity called inside a parallel batch, use Commands.Spawn")
+	}
 	var entityID int
 	if r.freeIDs.Len() == 0 {
 		r.numEntities++
 		entityID = r.numEntities
 		if entityID >= len(r.entityComponentSignatures) {
-			// WARNING newSize := entityID + 1 // This is insane but thats the code in pikuma.com
-			newSize := int(float32(len(r.entityComponentSignatures)) * 1.5)
-			r.logger.Info(fmt.Sprintf("resize entityComponentSignatures %d -> %d", len(r.entityComponentSignatures), newSize), nil)
-			newSignatureSlice := make([]bitset.Bitset32, newSize)
-			for i := 0; i < len(r.entityComponentSignatures); i++ {
-				newSignatureSlice[i] = r.entityComponentSignatures[i]
-			}
-			r.entityComponentSignatures = newSignatureSlice
+			r.growEntities()
 		}
 	} else {
 		frontElement := r.freeIDs.Front()
 		entityID = frontElement.Value.(int)
 		r.freeIDs.Remove(frontElement)
 	}
-	entity := NewEntity(entityID)
-
-	// Handle entities to be added
-	var exists bool
-	for _, e := range r.entitiesToBeAdded {
-		if e.GetID() == entity.GetID() {
-			exists = true
-			break
-		}
-	}
-	if !exists {
-		r.entitiesToBeAdded = append(r.entitiesToBeAdded, entity)
+	if r.entityComponentSignatures[entityID] == nil {
+		r.entityComponentSignatures[entityID] = bitset.NewBitsetN(r.maxComponentCount)
 	}
+	entity := NewEntity(entityID, r.entityGenerations[entityID])
+	r.entityAlive[entityID] = true
 
-	r.logger.Debug(fmt.Sprintf("Entity{%d} created", entityID), nil)
+	r.queueSignatureChange(entity)
+
+	r.logger.Debug(fmt.Sprintf("%s created", entity), nil)
 	return entity
 }
 
+func (r *Registry) growEntities() {
+	// WARNING newSize := entityID + 1 // This is insane but thats the code in pikuma.com
+	newSize := int(float32(len(r.entityComponentSignatures)) * 1.5)
+	r.logger.Info(fmt.Sprintf("resize entityComponentSignatures %d -> %d", len(r.entityComponentSignatures), newSize), nil)
+	newSignatureSlice := make([]*bitset.BitsetN, newSize)
+	for i := 0; i < len(r.entityComponentSignatures); i++ {
+		newSignatureSlice[i] = r.entityComponentSignatures[i]
+	}
+	r.entityComponentSignatures = newSignatureSlice
+
+	newGenerationSlice := make([]uint32, newSize)
+	copy(newGenerationSlice, r.entityGenerations)
+	r.entityGenerations = newGenerationSlice
+
+	newAliveSlice := make([]bool, newSize)
+	copy(newAliveSlice, r.entityAlive)
+	r.entityAlive = newAliveSlice
+}
+
+// KillEntity queues entity and its children to be removed on the next Update.
+// Killing a stale handle is a no-op. Inside a parallel batch the kill is
+// recorded on Registry.Commands, which has the same effect.
 func (r *Registry) KillEntity(entity Entity) {
-	r.logger.Debug(fmt.Sprintf("Entity killed with id = %d", entity.GetID()), nil)
+	if r.parallel.Load() {
+		r.commands.Kill(entity)
+		return
+	}
+	if !r.IsAlive(entity) {
+		r.logger.Debug(fmt.Sprintf("%s is not alive, ignoring kill", entity), nil)
+		return
+	}
+	r.logger.Debug(fmt.Sprintf("%s killed", entity), nil)
 	// Handle entities to be added
 	var exists bool
 	for _, e := range r.entitiesToBeKilled {
-		if e.GetID() == entity.GetID() {
+		if e == entity {
 			exists = true
 			break
 		}
@@ -111,73 +197,101 @@ func (r *Registry) KillEntity(entity Entity) {
 	}
 }
 
-// COMPONENT MANAGEMENT ////////////////////
-func (r *Registry) AddComponent(entity Entity, componentID ComponentTypeID, component interface{}) error {
+// IsAlive reports whether entity was created by r and has not been removed by
+// Update since. Entities queued by KillEntity stay alive until the next Update.
+// Handles guessing the generation of a free id are not alive.
+func (r *Registry) IsAlive(entity Entity) bool {
 	entityID := entity.GetID()
-
-	// Check if the component pool exists, if not, create it
-	if _, exists := r.componentPools[componentID]; !exists {
-		initialCapacity := utils.Max(r.numEntities, 8) // Start with at least 8 elements
-		r.componentPools[componentID] = reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(component)), 0, initialCapacity).Interface()
+	if entityID <= 0 || entityID > r.numEntities {
+		return false
 	}
+	return r.entityAlive[entityID] && r.entityGenerations[entityID] == entity.Generation
+}
 
-	// Get the component pool and ensure it's large enough
-	pool := reflect.ValueOf(r.componentPools[componentID])
-	if entityID >= pool.Cap() {
-		newCapacity := utils.Max(entityID+1, int(math.Ceil(float64(pool.Cap())*1.5)))
-		newPool := reflect.MakeSlice(pool.Type(), pool.Len(), newCapacity)
-		reflect.Copy(newPool, pool)
-		pool = newPool
-		r.componentPools[componentID] = pool.Interface()
+// queueSignatureChange schedules entity to have its system membership
+// re-evaluated on the next Update.
+func (r *Registry) queueSignatureChange(entity Entity) {
+	if r.pendingUpdates.Contains(entity) {
+		return
 	}
+	r.pendingUpdates.Add(entity)
+	r.entitiesToBeUpdated = append(r.entitiesToBeUpdated, entity)
+}
 
-	// Extend the slice if necessary
-	if entityID >= pool.Len() {
-		pool = pool.Slice(0, entityID+1)
-		r.componentPools[componentID] = pool.Interface()
+func (r *Registry) liveEntities() []Entity {
+	entities := make([]Entity, 0, r.numEntities-r.freeIDs.Len())
+	for entityID := 1; entityID <= r.numEntities; entityID++ {
+		if r.entityAlive[entityID] {
+			entities = append(entities, NewEntity(entityID, r.entityGenerations[entityID]))
+		}
 	}
-
-	// Add the component to the pool
-	pool.Index(entityID).Set(reflect.ValueOf(component))
-
-	// Update the entity's component signature
-	r.entityComponentSignatures[entityID].Set(int(componentID))
-
-	return nil
+	return entities
 }
 
-func (r *Registry) RemoveComponent(entity Entity, componentID ComponentTypeID) {
+// COMPONENT MANAGEMENT ////////////////////
+func (r *Registry) removeComponent(entity Entity, componentID ComponentTypeID) {
+	if !r.hasComponent(entity, componentID) {
+		return
+	}
+	runHooks(r, r.hooksOf(componentID).onRemove, entity)
 	entityID := entity.GetID()
+	if pool := r.getPool(componentID); pool != nil {
+		pool.Remove(entityID)
+	}
 	r.entityComponentSignatures[entityID].Clear(int(componentID))
+	r.queueSignatureChange(entity)
 }
 
-func (r *Registry) HasComponent(entity Entity, componentID ComponentTypeID) bool {
+func (r *Registry) hasComponent(entity Entity, componentID ComponentTypeID) bool {
 	entityID := entity.GetID()
 	signature := r.entityComponentSignatures[entityID]
 	return signature.IsSet(int(componentID))
 }
 
-func (r *Registry) GetComponent(entity Entity, componentID ComponentTypeID) interface{} {
-	pool := reflect.ValueOf(r.componentPools[componentID])
-	return pool.Index(entity.GetID()).Interface()
-}
-
-func (r *Registry) GetComponentPtr(entity Entity, componentID ComponentTypeID) interface{} {
-	pool := reflect.ValueOf(r.componentPools[componentID])
-	return pool.Index(entity.GetID()).Addr().Interface()
+func (r *Registry) getPool(componentID ComponentTypeID) componentPool {
+	if int(componentID) >= len(r.componentPools) {
+		return nil
+	}
+	return r.componentPools[componentID]
 }
 
 // SYSTEM MANAGEMENT ////////////////////
-func (r *Registry) AddSystem(systemID SystemTypeID, system System) {
+func (r *Registry) AddSystem(systemID SystemTypeID, system System, opts ...SystemOption) error {
+	if err := system.Err(); err != nil {
+		return fmt.Errorf("%s: %w", system.GetName(), err)
+	}
 	_, exists := r.systems[systemID]
 	if !exists {
+		scheduled := &scheduledSystem{stage: StageUpdate, enabled: true}
+		for _, opt := range opts {
+			if err := opt(r, scheduled); err != nil {
+				return fmt.Errorf("%s: %w", system.GetName(), err)
+			}
+		}
 		r.systems[systemID] = system
-		r.logger.Info(fmt.Sprintf("%s{%d} is registered", system.GetName(), systemID), nil)
+		r.schedule[systemID] = scheduled
+		r.scheduleDirty = true
+		r.logger.Info(fmt.Sprintf("%s{%d} is registered in %s", system.GetName(), systemID, scheduled.stage), nil)
+		// Entities that already exist join the system on the next Update
+		for _, entity := range r.liveEntities() {
+			r.queueSignatureChange(entity)
+		}
 	}
+	return nil
 }
 
+// RemoveSystem removes a system and tears it down, see BaseSystem.AddTeardown.
 func (r *Registry) RemoveSystem(systemID SystemTypeID) {
+	system, exists := r.systems[systemID]
+	if !exists {
+		return
+	}
+	if teardown, ok := system.(systemTeardown); ok {
+		teardown.Teardown()
+	}
 	delete(r.systems, systemID)
+	delete(r.schedule, systemID)
+	r.scheduleDirty = true
 }
 
 func (r *Registry) GetSystem(systemID SystemTypeID) System {
@@ -189,30 +303,71 @@ func (r *Registry) HasSystem(systemID SystemTypeID) bool {
 	return exists
 }
 
+// Update applies the changes queued since the last call: recorded commands
+// run first, entities join or leave systems according to their current
+// signature, then killed entities are removed. It ends the frame for the
+// Added and Changed query filters.
 func (r *Registry) Update() {
-	for _, entity := range r.entitiesToBeAdded {
-		r.AddEntityToSystems(entity)
+	if err := r.Apply(r.commands); err != nil {
+		r.logger.Error(err, "failed to apply commands", nil)
 	}
-	r.entitiesToBeAdded = r.entitiesToBeAdded[:0]
 
-	for _, entity := range r.entitiesToBeKilled {
+	for _, entity := range r.entitiesToBeUpdated {
+		if r.IsAlive(entity) {
+			r.UpdateEntitySystems(entity)
+		}
+	}
+	r.entitiesToBeUpdated = r.entitiesToBeUpdated[:0]
+	r.pendingUpdates.Clear()
+
+	// Killing an entity queues its children, so the slice grows while looping
+	for i := 0; i < len(r.entitiesToBeKilled); i++ {
+		entity := r.entitiesToBeKilled[i]
+		r.unlink(entity)
+		runHooks(r, r.killHooks, entity)
+		for componentID, pool := range r.componentPools {
+			if pool != nil && r.hasComponent(entity, ComponentTypeID(componentID)) {
+				runHooks(r, r.hooksOf(ComponentTypeID(componentID)).onRemove, entity)
+			}
+		}
 		r.RemoveEntityFromSystems(entity)
+		r.forgetLabels(entity)
+		for componentID, pool := range r.componentPools {
+			if pool != nil && r.hasComponent(entity, ComponentTypeID(componentID)) {
+				pool.Remove(entity.GetID())
+			}
+		}
 		r.entityComponentSignatures[entity.GetID()].Reset()
+		r.entityGenerations[entity.GetID()]++
+		r.entityAlive[entity.GetID()] = false
 		r.freeIDs.PushFront(entity.GetID())
 	}
 	r.entitiesToBeKilled = r.entitiesToBeKilled[:0]
+	r.tick++
 }
 
-func (r *Registry) AddEntityToSystems(entity Entity) {
+// UpdateEntitySystems adds entity to the systems and queries its signature
+// matches and removes it from the ones it no longer matches.
+func (r *Registry) UpdateEntitySystems(entity Entity) {
 	entitySignature := r.entityComponentSignatures[entity.GetID()]
+	for _, query := range r.queries {
+		query.update(entity, entitySignature)
+	}
 	for _, system := range r.systems {
-		if (entitySignature.Get32() & system.GetSignature().Get32()) == system.GetSignature().Get32() {
+		matches := system.GetQuery().Matches(entitySignature)
+		member := system.HasEntity(entity)
+		if matches && !member {
 			system.AddEntityToSystem(entity)
+		} else if !matches && member {
+			system.RemoveEntityFromSystem(entity)
 		}
 	}
 }
 
 func (r *Registry) RemoveEntityFromSystems(entity Entity) {
+	for _, query := range r.queries {
+		query.remove(entity)
+	}
 	for _, system := range r.systems {
 		system.RemoveEntityFromSystem(entity)
 	}
@@ -0,0 +1,84 @@
+package ecs
+
+import "testing"
+
+func TestIsAlive(t *testing.T) {
+	r := newTestRegistry()
+	if r.IsAlive(Entity{}) {
+		t.Error("Expected zero value entity to not be alive")
+	}
+	e := r.CreateEntity()
+	if !r.IsAlive(e) {
+		t.Errorf("Expected %s to be alive", e)
+	}
+	r.KillEntity(e)
+	if !r.IsAlive(e) {
+		t.Errorf("Expected %s to stay alive until Update", e)
+	}
+	r.Update()
+	if r.IsAlive(e) {
+		t.Errorf("Expected %s to be dead after Update", e)
+	}
+}
+
+func TestStaleHandleAfterIDReuse(t *testing.T) {
+	r := newTestRegistry()
+	old := r.CreateEntity()
+	Add(r, old, position{X: 1})
+	r.KillEntity(old)
+	r.Update()
+
+	reused := r.CreateEntity()
+	if reused.GetID() != old.GetID() {
+		t.Fatalf("Expected id %d to be reused, got %d", old.GetID(), reused.GetID())
+	}
+	if reused.Generation == old.Generation {
+		t.Fatalf("Expected a new generation for reused id, got %d", reused.Generation)
+	}
+	Add(r, reused, position{X: 2})
+
+	if _, err := Get[position](r, old); err != ErrEntityNotAlive {
+		t.Errorf("Expected ErrEntityNotAlive for stale handle, got %v", err)
+	}
+	if err := Add(r, old, velocity{}); err != ErrEntityNotAlive {
+		t.Errorf("Expected ErrEntityNotAlive when adding to stale handle, got %v", err)
+	}
+	if err := Remove[position](r, old); err != ErrEntityNotAlive {
+		t.Errorf("Expected ErrEntityNotAlive when removing from stale handle, got %v", err)
+	}
+	if Has[position](r, old) {
+		t.Error("Expected stale handle to have no components")
+	}
+
+	r.KillEntity(old)
+	r.Update()
+	if !r.IsAlive(reused) {
+		t.Error("Expected killing a stale handle to leave the new entity alive")
+	}
+	if p, _ := Get[position](r, reused); p == nil || p.X != 2 {
+		t.Errorf("Expected new entity's component to be untouched, got %+v", p)
+	}
+}
+
+func TestHandleOfFreeIDIsNotAlive(t *testing.T) {
+	r := newTestRegistry()
+	e := r.CreateEntity()
+	r.KillEntity(e)
+	r.Update()
+
+	// the generation the id gets when it is reused
+	future := NewEntity(e.GetID(), e.Generation+1)
+	if r.IsAlive(future) {
+		t.Errorf("Expected %s to not be alive while its id is free", future)
+	}
+	if err := Add(r, future, position{}); err != ErrEntityNotAlive {
+		t.Errorf("Expected ErrEntityNotAlive when adding through %s, got %v", future, err)
+	}
+	created := r.CreateEntity()
+	if created != future {
+		t.Fatalf("Expected the id to be reused as %s, got %s", future, created)
+	}
+	if Has[position](r, created) {
+		t.Error("Expected the new entity to have no components written through the free id")
+	}
+}
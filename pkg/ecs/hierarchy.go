@@ -0,0 +1,114 @@
+package ecs
+
+import (
+	"errors"
+	"slices"
+)
+
+var (
+	ErrHierarchyCycle = errors.New("entity can not be its own ancestor")
+)
+
+// Parent is added to entities attached to another entity with SetParent.
+// Change it through SetParent and RemoveParent only.
+type Parent struct {
+	Entity Entity
+}
+
+// Children lists the entities attached to an entity in the order they were
+// attached. Killing an entity kills its children too.
+type Children struct {
+	Entities []Entity
+}
+
+// SetParent attaches child to parent, detaching it from its previous parent.
+func SetParent(r *Registry, child, parent Entity) error {
+	if !r.IsAlive(child) || !r.IsAlive(parent) {
+		return ErrEntityNotAlive
+	}
+	for ancestor, ok := parent, true; ok; ancestor, ok = GetParent(r, ancestor) {
+		if ancestor == child {
+			return ErrHierarchyCycle
+		}
+	}
+	if err := RemoveParent(r, child); err != nil {
+		return err
+	}
+	if children, err := Get[Children](r, parent); err == nil {
+		children.Entities = append(children.Entities, child)
+	} else if err := Add(r, parent, Children{Entities: []Entity{child}}); err != nil {
+		return err
+	}
+	return Add(r, child, Parent{Entity: parent})
+}
+
+// RemoveParent detaches child from its parent, it is a no-op for entities
+// without a parent.
+func RemoveParent(r *Registry, child Entity) error {
+	if !r.IsAlive(child) {
+		return ErrEntityNotAlive
+	}
+	p, err := Get[Parent](r, child)
+	if err != nil {
+		return nil
+	}
+	r.removeChild(p.Entity, child)
+	return Remove[Parent](r, child)
+}
+
+func GetParent(r *Registry, child Entity) (Entity, bool) {
+	p, err := Get[Parent](r, child)
+	if err != nil {
+		return Entity{}, false
+	}
+	return p.Entity, true
+}
+
+// GetChildren returns the children of parent. The slice is owned by the
+// registry.
+func GetChildren(r *Registry, parent Entity) []Entity {
+	children, err := Get[Children](r, parent)
+	if err != nil {
+		return nil
+	}
+	return children.Entities
+}
+
+// SetParentDeferred records attaching child to parent.
+func SetParentDeferred(c *Commands, child, parent Entity) {
+	c.push(func(r *Registry, spawned *placeholders) error {
+		child, err := resolve(child, spawned)
+		if err != nil {
+			return err
+		}
+		parent, err := resolve(parent, spawned)
+		if err != nil {
+			return err
+		}
+		return SetParent(r, child, parent)
+	})
+}
+
+func (r *Registry) removeChild(parent, child Entity) {
+	children, err := Get[Children](r, parent)
+	if err != nil {
+		return
+	}
+	children.Entities = slices.DeleteFunc(children.Entities, func(e Entity) bool {
+		return e == child
+	})
+	if len(children.Entities) == 0 {
+		Remove[Children](r, parent)
+	}
+}
+
+// unlink detaches an entity that is being killed from the hierarchy and
+// queues its children to be killed with it.
+func (r *Registry) unlink(entity Entity) {
+	for _, child := range GetChildren(r, entity) {
+		r.KillEntity(child)
+	}
+	if parent, ok := GetParent(r, entity); ok {
+		r.removeChild(parent, entity)
+	}
+}
@@ -0,0 +1,89 @@
+package ecs
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSetParent(t *testing.T) {
+	r := newTestRegistry()
+	a := r.CreateEntity()
+	b := r.CreateEntity()
+	child := r.CreateEntity()
+
+	if err := SetParent(r, child, a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := SetParent(r, child, b); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if parent, ok := GetParent(r, child); !ok || parent != b {
+		t.Errorf("Expected parent %s, got %s (ok=%v)", b, parent, ok)
+	}
+	if Has[Children](r, a) {
+		t.Errorf("Expected %s to lose its only child", a)
+	}
+	if children := GetChildren(r, b); !slices.Equal(children, []Entity{child}) {
+		t.Errorf("Expected children [%s], got %v", child, children)
+	}
+
+	if err := SetParent(r, b, child); !errors.Is(err, ErrHierarchyCycle) {
+		t.Errorf("Expected ErrHierarchyCycle, got %v", err)
+	}
+	if err := SetParent(r, a, a); !errors.Is(err, ErrHierarchyCycle) {
+		t.Errorf("Expected ErrHierarchyCycle, got %v", err)
+	}
+
+	RemoveParent(r, child)
+	if Has[Parent](r, child) || Has[Children](r, b) {
+		t.Error("Expected RemoveParent to detach the child")
+	}
+}
+
+func TestKillCascadesToChildren(t *testing.T) {
+	r := newTestRegistry()
+	root := r.CreateEntity()
+	child := r.CreateEntity()
+	grandchild := r.CreateEntity()
+	sibling := r.CreateEntity()
+	SetParent(r, child, root)
+	SetParent(r, grandchild, child)
+	SetParent(r, sibling, root)
+	r.Update()
+
+	r.KillEntity(child)
+	r.Update()
+	if r.IsAlive(child) || r.IsAlive(grandchild) {
+		t.Error("Expected the child and grandchild to be killed")
+	}
+	if !r.IsAlive(root) || !r.IsAlive(sibling) {
+		t.Error("Expected the root and sibling to stay alive")
+	}
+	if children := GetChildren(r, root); !slices.Equal(children, []Entity{sibling}) {
+		t.Errorf("Expected the killed child to be detached, got %v", children)
+	}
+
+	// Children attached after the kill is queued are killed too
+	r.KillEntity(root)
+	late := r.CreateEntity()
+	SetParent(r, late, root)
+	r.Update()
+	if r.IsAlive(sibling) || r.IsAlive(late) {
+		t.Error("Expected every child of the root to be killed")
+	}
+}
+
+func TestSetParentDeferred(t *testing.T) {
+	r := newTestRegistry()
+	parent := r.CreateEntity()
+	cmds := r.Commands()
+	child := cmds.Spawn()
+	SetParentDeferred(cmds, child, parent)
+	r.Update()
+
+	children := GetChildren(r, parent)
+	if len(children) != 1 || !r.IsAlive(children[0]) {
+		t.Errorf("Expected the spawned entity to be attached, got %v", children)
+	}
+}
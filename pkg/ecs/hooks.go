@@ -0,0 +1,120 @@
+package ecs
+
+import (
+	"slices"
+)
+
+// Hook is called with the entity whose component or lifetime changed. Hooks
+// run synchronously inside the call that made the change, so hooks of
+// components written by concurrent systems must be safe for concurrent use.
+// Structural changes made by hooks should go through Registry.Commands.
+type Hook func(r *Registry, entity Entity)
+
+// registeredHook identifies a hook so it can be removed, registering the same
+// function twice registers two hooks.
+type registeredHook struct {
+	id   uint64
+	hook Hook
+}
+
+type componentHooks struct {
+	onAdd    []registeredHook
+	onRemove []registeredHook
+	onSet    []registeredHook
+}
+
+// OnAdd registers hook to run after a T component is added to an entity that
+// didn't have one. It returns a func removing the hook, systems can pass it
+// to BaseSystem.AddTeardown.
+func OnAdd[T any](r *Registry, hook Hook) (func(), error) {
+	hooks, err := hooksFor[T](r)
+	if err != nil {
+		return nil, err
+	}
+	return r.addHook(&hooks.onAdd, hook), nil
+}
+
+// OnRemove registers hook to run before a T component is removed, either by
+// Remove or because its entity is killed. The component can still be read.
+// It returns a func removing the hook.
+func OnRemove[T any](r *Registry, hook Hook) (func(), error) {
+	hooks, err := hooksFor[T](r)
+	if err != nil {
+		return nil, err
+	}
+	return r.addHook(&hooks.onRemove, hook), nil
+}
+
+// OnSet registers hook to run after a T component is written by Add or
+// flagged by MarkChanged. It returns a func removing the hook.
+func OnSet[T any](r *Registry, hook Hook) (func(), error) {
+	hooks, err := hooksFor[T](r)
+	if err != nil {
+		return nil, err
+	}
+	return r.addHook(&hooks.onSet, hook), nil
+}
+
+// OnKill registers hook to run when a killed entity is removed by Update,
+// before its components are. It returns a func removing the hook.
+func (r *Registry) OnKill(hook Hook) func() {
+	return r.addHook(&r.killHooks, hook)
+}
+
+func (r *Registry) addHook(hooks *[]registeredHook, hook Hook) func() {
+	r.lastHookID++
+	id := r.lastHookID
+	*hooks = append(*hooks, registeredHook{id: id, hook: hook})
+	return func() {
+		// Hooks are copied on removal because they may be running
+		*hooks = slices.DeleteFunc(slices.Clone(*hooks), func(h registeredHook) bool {
+			return h.id == id
+		})
+	}
+}
+
+// MarkChanged flags the T component of entity as changed after it was
+// modified through the pointer returned by Get or an iterator. It runs the
+// OnSet hooks and makes the entity pass Changed[T] filters.
+func MarkChanged[T any](r *Registry, entity Entity) error {
+	if !r.IsAlive(entity) {
+		return ErrEntityNotAlive
+	}
+	componentID, err := ComponentID[T](r)
+	if err != nil {
+		return err
+	}
+	pool := r.getPool(componentID)
+	if pool == nil || !pool.Has(entity.GetID()) {
+		return ErrComponentNotFound
+	}
+	pool.stamp(entity.GetID(), r.tick, false)
+	runHooks(r, r.hooksOf(componentID).onSet, entity)
+	return nil
+}
+
+func hooksFor[T any](r *Registry) (*componentHooks, error) {
+	componentID, err := ComponentID[T](r)
+	if err != nil {
+		return nil, err
+	}
+	hooks, exists := r.hooks[componentID]
+	if !exists {
+		hooks = &componentHooks{}
+		r.hooks[componentID] = hooks
+	}
+	return hooks, nil
+}
+
+func (r *Registry) hooksOf(componentID ComponentTypeID) componentHooks {
+	if hooks, exists := r.hooks[componentID]; exists {
+		return *hooks
+	}
+	return componentHooks{}
+}
+
+func runHooks(r *Registry, hooks []registeredHook, entity Entity) {
+	for _, h := range hooks {
+		h.hook(r, entity)
+	}
+}
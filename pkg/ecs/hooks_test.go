@@ -0,0 +1,139 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestComponentHooks(t *testing.T) {
+	r := newTestRegistry()
+	events := make([]string, 0)
+	record := func(name string) Hook {
+		return func(r *Registry, entity Entity) {
+			if name == "remove" && !Has[position](r, entity) {
+				t.Error("Expected the component to be readable in OnRemove")
+			}
+			events = append(events, name)
+		}
+	}
+	OnAdd[position](r, record("add"))
+	OnSet[position](r, record("set"))
+	OnRemove[position](r, record("remove"))
+	r.OnKill(record("kill"))
+
+	e := r.CreateEntity()
+	Add(r, e, position{})
+	Add(r, e, position{X: 1})
+	MarkChanged[position](r, e)
+	Add(r, e, velocity{})
+	Remove[position](r, e)
+	Remove[position](r, e)
+	Add(r, e, position{})
+	r.KillEntity(e)
+	r.Update()
+
+	expected := []string{"add", "set", "set", "set", "remove", "add", "set", "kill", "remove"}
+	if !slices.Equal(events, expected) {
+		t.Errorf("Expected hooks %v, got %v", expected, events)
+	}
+	if err := MarkChanged[position](r, e); err != ErrEntityNotAlive {
+		t.Errorf("Expected ErrEntityNotAlive, got %v", err)
+	}
+}
+
+func TestRemoveHooks(t *testing.T) {
+	r := newTestRegistry()
+	calls := 0
+	count := func(r *Registry, entity Entity) {
+		calls++
+	}
+	removeAdd, _ := OnAdd[position](r, count)
+	removeSet, _ := OnSet[position](r, count)
+	removeRemove, _ := OnRemove[position](r, count)
+	removeKill := r.OnKill(count)
+	// the same func registered twice is removed once
+	OnSet[position](r, count)
+	removeAdd()
+	removeSet()
+	removeRemove()
+	removeKill()
+	removeKill()
+
+	e := r.CreateEntity()
+	Add(r, e, position{})
+	r.KillEntity(e)
+	r.Update()
+	if calls != 1 {
+		t.Errorf("Expected only the hook left to run, got %d calls", calls)
+	}
+}
+
+func TestRemoveHookWhileRunning(t *testing.T) {
+	r := newTestRegistry()
+	events := make([]string, 0)
+	var removeFirst func()
+	removeFirst, _ = OnSet[position](r, func(r *Registry, entity Entity) {
+		events = append(events, "first")
+		removeFirst()
+	})
+	OnSet[position](r, func(r *Registry, entity Entity) {
+		events = append(events, "second")
+	})
+
+	e := r.CreateEntity()
+	Add(r, e, position{})
+	Add(r, e, position{})
+	expected := []string{"first", "second", "second"}
+	if !slices.Equal(events, expected) {
+		t.Errorf("Expected hooks %v, got %v", expected, events)
+	}
+}
+
+func TestAddedAndChangedFilters(t *testing.T) {
+	r := newTestRegistry()
+	added, _ := NewQuery(r, Added[position]())
+	changed, _ := NewQuery(r, Changed[position]())
+	collect := func(q *Query) []Entity {
+		return slices.Collect(q.All())
+	}
+
+	a := r.CreateEntity()
+	b := r.CreateEntity()
+	Add(r, a, position{})
+	r.Update()
+	Add(r, b, position{})
+	if got := collect(added); !slices.Equal(got, []Entity{a}) {
+		t.Errorf("Expected only %s to be added in the previous frame, got %v", a, got)
+	}
+	if changed.Len() != 1 {
+		t.Errorf("Expected filters to not affect membership, got %d", changed.Len())
+	}
+
+	r.Update()
+	p, _ := Get[position](r, a)
+	p.X++
+	MarkChanged[position](r, a)
+	if got := collect(added); !slices.Equal(got, []Entity{b}) {
+		t.Errorf("Expected only %s to be added in the previous frame, got %v", b, got)
+	}
+
+	r.Update()
+	if got := collect(added); len(got) != 0 {
+		t.Errorf("Expected no added entities, got %v", got)
+	}
+	count := 0
+	for e, p := range Iter[position](changed) {
+		if e != a || p.X != 1 {
+			t.Errorf("Expected only %s to be changed, got %s %+v", a, e, p)
+		}
+		count++
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 changed entity, got %d", count)
+	}
+
+	r.Update()
+	if got := collect(changed); len(got) != 0 {
+		t.Errorf("Expected changes to be visible for one frame, got %v", got)
+	}
+}
@@ -0,0 +1,189 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrNameTaken = errors.New("entity name already taken")
+)
+
+// entityList is a set of entities that can be read as a slice.
+type entityList struct {
+	entities []Entity
+	// [key = entity] [value = index in entities]
+	indices map[Entity]int
+}
+
+func newEntityList() *entityList {
+	return &entityList{
+		entities: make([]Entity, 0),
+		indices:  make(map[Entity]int),
+	}
+}
+
+func (l *entityList) add(entity Entity) {
+	if _, exists := l.indices[entity]; exists {
+		return
+	}
+	l.indices[entity] = len(l.entities)
+	l.entities = append(l.entities, entity)
+}
+
+func (l *entityList) remove(entity Entity) {
+	index, exists := l.indices[entity]
+	if !exists {
+		return
+	}
+	last := len(l.entities) - 1
+	l.entities[index] = l.entities[last]
+	l.indices[l.entities[index]] = index
+	l.entities = l.entities[:last]
+	delete(l.indices, entity)
+}
+
+// labels holds the optional name and the tags of entities.
+type labels struct {
+	names map[string]Entity
+	// [key = entity id]
+	nameOf map[int]string
+	tags   map[string]*entityList
+	// [key = entity id]
+	tagsOf map[int][]string
+}
+
+func newLabels() *labels {
+	return &labels{
+		names:  make(map[string]Entity),
+		nameOf: make(map[int]string),
+		tags:   make(map[string]*entityList),
+		tagsOf: make(map[int][]string),
+	}
+}
+
+// SetName gives entity a name unique in the registry, replacing its previous
+// name. An empty name removes it.
+func (r *Registry) SetName(entity Entity, name string) error {
+	if !r.IsAlive(entity) {
+		return ErrEntityNotAlive
+	}
+	if owner, exists := r.labels.names[name]; exists && owner != entity {
+		return fmt.Errorf("%w: %q is %s", ErrNameTaken, name, owner)
+	}
+	if previous, exists := r.labels.nameOf[entity.GetID()]; exists {
+		delete(r.labels.names, previous)
+		delete(r.labels.nameOf, entity.GetID())
+	}
+	if name != "" {
+		r.labels.names[name] = entity
+		r.labels.nameOf[entity.GetID()] = name
+	}
+	return nil
+}
+
+// GetName returns the name of entity, or "" when it has none.
+func (r *Registry) GetName(entity Entity) string {
+	if !r.IsAlive(entity) {
+		return ""
+	}
+	return r.labels.nameOf[entity.GetID()]
+}
+
+func (r *Registry) FindByName(name string) (Entity, bool) {
+	entity, exists := r.labels.names[name]
+	return entity, exists
+}
+
+func (r *Registry) AddTag(entity Entity, tag string) error {
+	if !r.IsAlive(entity) {
+		return ErrEntityNotAlive
+	}
+	if r.HasTag(entity, tag) {
+		return nil
+	}
+	list, exists := r.labels.tags[tag]
+	if !exists {
+		list = newEntityList()
+		r.labels.tags[tag] = list
+	}
+	list.add(entity)
+	r.labels.tagsOf[entity.GetID()] = append(r.labels.tagsOf[entity.GetID()], tag)
+	return nil
+}
+
+func (r *Registry) RemoveTag(entity Entity, tag string) {
+	if !r.HasTag(entity, tag) {
+		return
+	}
+	r.labels.tags[tag].remove(entity)
+	if len(r.labels.tags[tag].entities) == 0 {
+		delete(r.labels.tags, tag)
+	}
+	tags := slices.DeleteFunc(r.labels.tagsOf[entity.GetID()], func(t string) bool {
+		return t == tag
+	})
+	if len(tags) == 0 {
+		delete(r.labels.tagsOf, entity.GetID())
+	} else {
+		r.labels.tagsOf[entity.GetID()] = tags
+	}
+}
+
+func (r *Registry) HasTag(entity Entity, tag string) bool {
+	list, exists := r.labels.tags[tag]
+	if !exists {
+		return false
+	}
+	_, tagged := list.indices[entity]
+	return tagged
+}
+
+// EntitiesWithTag returns the entities tagged with tag. The slice is owned by
+// the registry and changes when tags are added or removed.
+func (r *Registry) EntitiesWithTag(tag string) []Entity {
+	list, exists := r.labels.tags[tag]
+	if !exists {
+		return nil
+	}
+	return list.entities
+}
+
+// GetTags returns the tags of entity in the order they were added.
+func (r *Registry) GetTags(entity Entity) []string {
+	if !r.IsAlive(entity) {
+		return nil
+	}
+	return slices.Clone(r.labels.tagsOf[entity.GetID()])
+}
+
+// SetName records naming entity, see Registry.SetName.
+func (c *Commands) SetName(entity Entity, name string) {
+	c.push(func(r *Registry, spawned *placeholders) error {
+		entity, err := resolve(entity, spawned)
+		if err != nil {
+			return err
+		}
+		return r.SetName(entity, name)
+	})
+}
+
+// AddTag records tagging entity, see Registry.AddTag.
+func (c *Commands) AddTag(entity Entity, tag string) {
+	c.push(func(r *Registry, spawned *placeholders) error {
+		entity, err := resolve(entity, spawned)
+		if err != nil {
+			return err
+		}
+		return r.AddTag(entity, tag)
+	})
+}
+
+// forgetLabels removes the name and tags of a killed entity.
+func (r *Registry) forgetLabels(entity Entity) {
+	r.SetName(entity, "")
+	for _, tag := range r.GetTags(entity) {
+		r.RemoveTag(entity, tag)
+	}
+}
@@ -0,0 +1,91 @@
+package ecs
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEntityNames(t *testing.T) {
+	r := newTestRegistry()
+	a := r.CreateEntity()
+	b := r.CreateEntity()
+
+	r.SetName(a, "player")
+	if err := r.SetName(b, "player"); !errors.Is(err, ErrNameTaken) {
+		t.Errorf("Expected ErrNameTaken, got %v", err)
+	}
+	r.SetName(a, "hero")
+	if _, ok := r.FindByName("player"); ok {
+		t.Error("Expected renaming to free the old name")
+	}
+	if e, ok := r.FindByName("hero"); !ok || e != a || r.GetName(a) != "hero" {
+		t.Errorf("Expected %s to be named hero, got %s (ok=%v)", a, e, ok)
+	}
+
+	r.KillEntity(a)
+	r.Update()
+	if _, ok := r.FindByName("hero"); ok {
+		t.Error("Expected killing an entity to free its name")
+	}
+	reused := r.CreateEntity()
+	if r.GetName(reused) != "" {
+		t.Errorf("Expected reused id to have no name, got %q", r.GetName(reused))
+	}
+}
+
+func TestEntityTags(t *testing.T) {
+	r := newTestRegistry()
+	a := r.CreateEntity()
+	b := r.CreateEntity()
+	r.AddTag(a, "enemy")
+	r.AddTag(a, "enemy")
+	r.AddTag(a, "tank")
+	r.AddTag(b, "enemy")
+
+	if tagged := r.EntitiesWithTag("enemy"); len(tagged) != 2 {
+		t.Errorf("Expected 2 enemies, got %v", tagged)
+	}
+	if tags := r.GetTags(a); !slices.Equal(tags, []string{"enemy", "tank"}) {
+		t.Errorf("Expected tags [enemy tank], got %v", tags)
+	}
+	r.RemoveTag(a, "enemy")
+	if r.HasTag(a, "enemy") || !slices.Equal(r.EntitiesWithTag("enemy"), []Entity{b}) {
+		t.Errorf("Expected only %s to be an enemy, got %v", b, r.EntitiesWithTag("enemy"))
+	}
+
+	r.KillEntity(b)
+	r.Update()
+	if tagged := r.EntitiesWithTag("enemy"); len(tagged) != 0 {
+		t.Errorf("Expected killing an entity to remove its tags, got %v", tagged)
+	}
+}
+
+func TestLabelsInPrefabsAndSnapshots(t *testing.T) {
+	r := newSnapshotRegistry()
+	prefabs := `{
+		"mover": {"tags": ["moving"], "components": {"position": {}}},
+		"enemy": {"extends": "mover", "tags": ["enemy", "moving"]}
+	}`
+	if err := r.LoadPrefabs(strings.NewReader(prefabs)); err != nil {
+		t.Fatalf("Unexpected error loading prefabs: %v", err)
+	}
+	e, _ := r.Spawn("enemy")
+	if tags := r.GetTags(e); !slices.Equal(tags, []string{"moving", "enemy"}) {
+		t.Errorf("Expected inherited tags, got %v", tags)
+	}
+	r.SetName(e, "boss")
+	r.Update()
+
+	var buf bytes.Buffer
+	r.Save(&buf, SnapshotJSON)
+	loaded := newSnapshotRegistry()
+	if err := loaded.Load(&buf, SnapshotJSON); err != nil {
+		t.Fatalf("Unexpected error loading snapshot: %v", err)
+	}
+	if boss, ok := loaded.FindByName("boss"); !ok || boss != e || !loaded.HasTag(boss, "enemy") {
+		t.Errorf("Expected name and tags to be restored, got %s (ok=%v)", boss, ok)
+	}
+}
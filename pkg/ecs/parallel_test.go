@@ -0,0 +1,237 @@
+package ecs
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+type health struct {
+	Value int
+}
+
+type funcSystem struct {
+	*BaseSystem
+	update func(dt float32)
+}
+
+func newFuncSystem(r *Registry, name string, update func(dt float32)) *funcSystem {
+	return &funcSystem{
+		BaseSystem: NewBaseSystem(name, r.logger, r),
+		update:     update,
+	}
+}
+
+func (s *funcSystem) Update(dt float32) {
+	s.update(dt)
+}
+
+func TestBatchesFollowDeclaredAccess(t *testing.T) {
+	r := newTestRegistry()
+	noop := func(dt float32) {}
+	r.AddSystem(0, newFuncSystem(r, "movement", noop), Writes[position](), Reads[velocity]())
+	r.AddSystem(1, newFuncSystem(r, "regen", noop), Writes[health]())
+	r.AddSystem(2, newFuncSystem(r, "render", noop), Reads[position]())
+	r.AddSystem(3, newFuncSystem(r, "logic", noop))
+	r.AddSystem(4, newFuncSystem(r, "debug", noop), Reads[velocity](), Reads[health]())
+	if err := r.BuildSchedule(); err != nil {
+		t.Fatalf("Unexpected error building schedule: %v", err)
+	}
+
+	expected := [][]SystemTypeID{{0, 1}, {2}, {3}, {4}}
+	if !slices.EqualFunc(r.batches[StageUpdate], expected, slices.Equal) {
+		t.Errorf("Expected batches %v, got %v", expected, r.batches[StageUpdate])
+	}
+}
+
+func TestOrderingConstraintsSplitBatches(t *testing.T) {
+	r := newTestRegistry()
+	noop := func(dt float32) {}
+	r.AddSystem(0, newFuncSystem(r, "a", noop), Writes[position]())
+	r.AddSystem(1, newFuncSystem(r, "b", noop), Writes[velocity](), After(0))
+	r.BuildSchedule()
+
+	expected := [][]SystemTypeID{{0}, {1}}
+	if !slices.EqualFunc(r.batches[StageUpdate], expected, slices.Equal) {
+		t.Errorf("Expected batches %v, got %v", expected, r.batches[StageUpdate])
+	}
+}
+
+func TestNonConflictingSystemsRunConcurrently(t *testing.T) {
+	r := newTestRegistry()
+	r.SetWorkers(2)
+	started := make(chan struct{}, 2)
+	rendezvous := func(dt float32) {
+		started <- struct{}{}
+		deadline := time.After(time.Second)
+		for len(started) < 2 {
+			select {
+			case <-deadline:
+				t.Error("Expected the other system to run at the same time")
+				return
+			default:
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}
+	r.AddSystem(0, newFuncSystem(r, "a", rendezvous), Writes[position]())
+	r.AddSystem(1, newFuncSystem(r, "b", rendezvous), Writes[velocity]())
+	if err := r.RunStage(StageUpdate, 0); err != nil {
+		t.Fatalf("Unexpected error running stage: %v", err)
+	}
+}
+
+// The tests below are meant to be run with -race: systems sharing a batch
+// touch the registry concurrently and any missed conflict is reported.
+// Every system starts with a short sleep so that each worker picks a system
+// before any of them finishes, otherwise the detector may see the systems as
+// ordered by the scheduler's own synchronization.
+func raceSystem(r *Registry, name string, update func(dt float32)) *funcSystem {
+	return newFuncSystem(r, name, func(dt float32) {
+		time.Sleep(time.Millisecond)
+		update(dt)
+	})
+}
+
+func newParallelWorld(entities int) *Registry {
+	r := newTestRegistry()
+	r.SetWorkers(4)
+	for i := 0; i < entities; i++ {
+		e := r.CreateEntity()
+		Add(r, e, position{})
+		Add(r, e, velocity{X: 1, Y: 1})
+		Add(r, e, health{Value: 100})
+	}
+	r.Update()
+	return r
+}
+
+func TestParallelStageIsRaceFree(t *testing.T) {
+	r := newParallelWorld(500)
+	movers, _ := NewQuery(r, With[position](), With[velocity]())
+	living, _ := NewQuery(r, With[health]())
+
+	move := raceSystem(r, "move", func(dt float32) {
+		for _, c := range Iter2[position, velocity](movers) {
+			c.A.X += c.B.X * dt
+			c.A.Y += c.B.Y * dt
+		}
+	})
+	decay := raceSystem(r, "decay", func(dt float32) {
+		for _, h := range Iter[health](living) {
+			h.Value--
+		}
+	})
+	var sum float32
+	measure := raceSystem(r, "measure", func(dt float32) {
+		sum = 0
+		for _, p := range Iter[position](movers) {
+			sum += p.X
+		}
+	})
+	accelerate := raceSystem(r, "accelerate", func(dt float32) {
+		for _, v := range Iter[velocity](movers) {
+			v.X *= 2
+		}
+	})
+	r.AddSystem(0, move, Writes[position](), Reads[velocity]())
+	r.AddSystem(1, decay, Writes[health]())
+	r.AddSystem(2, measure, Reads[position]())
+	r.AddSystem(3, accelerate, Writes[velocity](), After(0))
+
+	for i := 0; i < 10; i++ {
+		if err := r.RunStage(StageUpdate, 1); err != nil {
+			t.Fatalf("Unexpected error running stage: %v", err)
+		}
+	}
+
+	// velocity doubles after every move: 1 + 2 + 4 + ... + 512
+	for e, c := range Iter2[position, health](movers) {
+		if c.A.X != 1023 || c.A.Y != 10 || c.B.Value != 90 {
+			t.Fatalf("Unexpected state for %s: %+v %+v", e, *c.A, *c.B)
+		}
+	}
+	if sum != 500*1023 {
+		t.Errorf("Expected measure to see every position after move, got %v", sum)
+	}
+}
+
+func TestParallelReadersShareComponents(t *testing.T) {
+	r := newParallelWorld(200)
+	q, _ := NewQuery(r, With[position](), With[health]())
+	totals := make([]int, 4)
+	for i := range totals {
+		reader := raceSystem(r, "reader", func(dt float32) {
+			for e := range q.All() {
+				h, err := Get[health](r, e)
+				if err == nil && Has[position](r, e) {
+					totals[i] += h.Value
+				}
+			}
+		})
+		r.AddSystem(SystemTypeID(i), reader, Reads[position](), Reads[health]())
+	}
+	r.BuildSchedule()
+	if len(r.batches[StageUpdate]) != 1 {
+		t.Fatalf("Expected all readers in one batch, got %v", r.batches[StageUpdate])
+	}
+	r.RunStage(StageUpdate, 0)
+	for i, total := range totals {
+		if total != 200*100 {
+			t.Errorf("Expected reader %d to see every entity, got %d", i, total)
+		}
+	}
+}
+
+type burning struct{}
+
+func TestParallelStructuralChangesGoThroughCommands(t *testing.T) {
+	r := newParallelWorld(200)
+	q, _ := NewQuery(r, With[position](), With[velocity]())
+	var directErrs [2]error
+	freeze := raceSystem(r, "freeze", func(dt float32) {
+		for e := range q.All() {
+			if err := Add(r, e, position{X: 5}); err != nil {
+				t.Errorf("Expected replacing a written component to work, got %v", err)
+			}
+			if err := Add(r, e, frozen{}); err != nil {
+				directErrs[0] = err
+			}
+			AddDeferred(r.Commands(), e, frozen{})
+		}
+	})
+	ignite := raceSystem(r, "ignite", func(dt float32) {
+		for e := range q.All() {
+			if err := Add(r, e, velocity{X: 5}); err != nil {
+				t.Errorf("Expected replacing a written component to work, got %v", err)
+			}
+			if err := Remove[health](r, e); err != nil {
+				directErrs[1] = err
+			}
+			AddDeferred(r.Commands(), e, burning{})
+		}
+	})
+	r.AddSystem(0, freeze, Writes[position](), Writes[frozen]())
+	r.AddSystem(1, ignite, Writes[velocity](), Writes[burning]())
+	r.BuildSchedule()
+	if len(r.batches[StageUpdate]) != 1 {
+		t.Fatalf("Expected both systems in one batch, got %v", r.batches[StageUpdate])
+	}
+
+	r.RunStage(StageUpdate, 0)
+	for i, err := range directErrs {
+		if !errors.Is(err, ErrParallelChange) {
+			t.Errorf("Expected system %d to get ErrParallelChange, got %v", i, err)
+		}
+	}
+	r.Update()
+	for e := range q.All() {
+		if !Has[frozen](r, e) || !Has[burning](r, e) || !Has[health](r, e) {
+			t.Fatalf("Expected the recorded changes only to be applied to %s", e)
+		}
+		if p, _ := Get[position](r, e); p.X != 5 {
+			t.Fatalf("Expected position of %s to be replaced, got %+v", e, *p)
+		}
+	}
+}
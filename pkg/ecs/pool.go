@@ -0,0 +1,151 @@
+package ecs
+
+type componentPool interface {
+	Has(entityID int) bool
+	Remove(entityID int)
+	Len() int
+	changeTicks(entityID int) (componentTicks, bool)
+	stamp(entityID int, tick uint64, added bool)
+	copyTo(dst *Registry, entityID int, to Entity) error
+}
+
+// componentTicks are the registry ticks a component was added and last
+// changed at.
+type componentTicks struct {
+	added   uint64
+	changed uint64
+}
+
+// Pool is a sparse set of components. Components are packed in a dense slice
+// and looked up through a sparse entity id -> index slice, so adding, removing
+// and reading are O(1) and iteration only touches live components.
+//
+// Pointers returned by Get point into the dense slice and are invalidated by
+// the next Set or Remove on the same pool.
+type Pool[T any] struct {
+	data     []T
+	entities []int
+	ticks    []componentTicks
+	// sparse holds index+1 for every entity id, 0 marks a missing component.
+	sparse []int
+}
+
+func NewPool[T any](capacity int) *Pool[T] {
+	return &Pool[T]{
+		data:     make([]T, 0, capacity),
+		entities: make([]int, 0, capacity),
+		ticks:    make([]componentTicks, 0, capacity),
+	}
+}
+
+func (p *Pool[T]) index(entityID int) (int, bool) {
+	if entityID < 0 || entityID >= len(p.sparse) || p.sparse[entityID] == 0 {
+		return -1, false
+	}
+	return p.sparse[entityID] - 1, true
+}
+
+func (p *Pool[T]) Set(entityID int, component T) {
+	if index, exists := p.index(entityID); exists {
+		p.data[index] = component
+		return
+	}
+	if entityID >= len(p.sparse) {
+		p.sparse = append(p.sparse, make([]int, entityID+1-len(p.sparse))...)
+	}
+	p.sparse[entityID] = len(p.data) + 1
+	p.data = append(p.data, component)
+	p.entities = append(p.entities, entityID)
+	p.ticks = append(p.ticks, componentTicks{})
+}
+
+func (p *Pool[T]) Get(entityID int) (*T, bool) {
+	index, exists := p.index(entityID)
+	if !exists {
+		return nil, false
+	}
+	return &p.data[index], true
+}
+
+func (p *Pool[T]) Has(entityID int) bool {
+	_, exists := p.index(entityID)
+	return exists
+}
+
+// Remove swaps the last component into the removed slot to keep the dense
+// slice packed.
+func (p *Pool[T]) Remove(entityID int) {
+	index, exists := p.index(entityID)
+	if !exists {
+		return
+	}
+	last := len(p.data) - 1
+	if index != last {
+		p.data[index] = p.data[last]
+		p.entities[index] = p.entities[last]
+		p.ticks[index] = p.ticks[last]
+		p.sparse[p.entities[index]] = index + 1
+	}
+	var zero T
+	p.data[last] = zero
+	p.data = p.data[:last]
+	p.entities = p.entities[:last]
+	p.ticks = p.ticks[:last]
+	p.sparse[entityID] = 0
+}
+
+func (p *Pool[T]) Len() int {
+	return len(p.data)
+}
+
+// Data returns the packed components. Data()[i] belongs to Entities()[i].
+func (p *Pool[T]) Data() []T {
+	return p.data
+}
+
+func (p *Pool[T]) Entities() []int {
+	return p.entities
+}
+
+func (p *Pool[T]) changeTicks(entityID int) (componentTicks, bool) {
+	index, exists := p.index(entityID)
+	if !exists {
+		return componentTicks{}, false
+	}
+	return p.ticks[index], true
+}
+
+func (p *Pool[T]) stamp(entityID int, tick uint64, added bool) {
+	index, exists := p.index(entityID)
+	if !exists {
+		return
+	}
+	if added {
+		p.ticks[index].added = tick
+	}
+	p.ticks[index].changed = tick
+}
+
+func (p *Pool[T]) copyTo(dst *Registry, entityID int, to Entity) error {
+	component, exists := p.Get(entityID)
+	if !exists {
+		return ErrComponentNotFound
+	}
+	return Add(dst, to, cloneComponent(component))
+}
+
+// Cloner is implemented by components holding slices, maps or pointers that
+// must not be shared between an entity and its copies.
+type Cloner[T any] interface {
+	Clone() T
+}
+
+func cloneComponent[T any](component *T) T {
+	if cloner, ok := any(*component).(Cloner[T]); ok {
+		return cloner.Clone()
+	}
+	if cloner, ok := any(component).(Cloner[T]); ok {
+		return cloner.Clone()
+	}
+	return *component
+}
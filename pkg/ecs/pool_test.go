@@ -0,0 +1,192 @@
+package ecs
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestPoolSetGet(t *testing.T) {
+	p := NewPool[position](0)
+	p.Set(3, position{X: 3})
+	p.Set(7, position{X: 7})
+	if p.Len() != 2 {
+		t.Errorf("Expected 2 components, got %d", p.Len())
+	}
+	c, ok := p.Get(7)
+	if !ok || c.X != 7 {
+		t.Errorf("Expected component of entity 7, got %+v (ok=%v)", c, ok)
+	}
+	p.Set(7, position{X: 70})
+	if c, _ := p.Get(7); c.X != 70 || p.Len() != 2 {
+		t.Errorf("Expected Set to overwrite the existing component, got %+v (len=%d)", c, p.Len())
+	}
+	if _, ok := p.Get(5); ok {
+		t.Error("Expected no component for entity 5")
+	}
+}
+
+func TestPoolRemoveKeepsDataPacked(t *testing.T) {
+	p := NewPool[position](0)
+	for i := 1; i <= 4; i++ {
+		p.Set(i, position{X: float32(i)})
+	}
+	p.Remove(2)
+	p.Remove(2)
+	if p.Len() != 3 {
+		t.Fatalf("Expected 3 components, got %d", p.Len())
+	}
+	if p.Has(2) {
+		t.Error("Expected entity 2 to be removed")
+	}
+	for i, entityID := range p.Entities() {
+		if p.Data()[i].X != float32(entityID) {
+			t.Errorf("Expected data[%d] to belong to entity %d, got %+v", i, entityID, p.Data()[i])
+		}
+		if c, _ := p.Get(entityID); c.X != float32(entityID) {
+			t.Errorf("Expected entity %d to map to its component, got %+v", entityID, c)
+		}
+	}
+}
+
+// reflectPool is the reflect based storage the registry used before Pool,
+// kept here to benchmark against.
+type reflectPool struct {
+	pool any
+}
+
+func (p *reflectPool) set(entityID int, component any) {
+	if p.pool == nil {
+		p.pool = reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(component)), 0, 8).Interface()
+	}
+	pool := reflect.ValueOf(p.pool)
+	if entityID >= pool.Cap() {
+		newCapacity := max(entityID+1, int(math.Ceil(float64(pool.Cap())*1.5)))
+		newPool := reflect.MakeSlice(pool.Type(), pool.Len(), newCapacity)
+		reflect.Copy(newPool, pool)
+		pool = newPool
+		p.pool = pool.Interface()
+	}
+	if entityID >= pool.Len() {
+		pool = pool.Slice(0, entityID+1)
+		p.pool = pool.Interface()
+	}
+	pool.Index(entityID).Set(reflect.ValueOf(component))
+}
+
+func (p *reflectPool) get(entityID int) any {
+	return reflect.ValueOf(p.pool).Index(entityID).Addr().Interface()
+}
+
+const benchEntities = 10000
+
+func BenchmarkPoolSet(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		p := NewPool[position](0)
+		for e := 0; e < benchEntities; e++ {
+			p.Set(e, position{X: float32(e)})
+		}
+	}
+}
+
+func BenchmarkReflectPoolSet(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		p := &reflectPool{}
+		for e := 0; e < benchEntities; e++ {
+			p.set(e, position{X: float32(e)})
+		}
+	}
+}
+
+func BenchmarkPoolGet(b *testing.B) {
+	p := NewPool[position](0)
+	for e := 0; e < benchEntities; e++ {
+		p.Set(e, position{})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for e := 0; e < benchEntities; e++ {
+			c, _ := p.Get(e)
+			c.X++
+		}
+	}
+}
+
+func BenchmarkReflectPoolGet(b *testing.B) {
+	p := &reflectPool{}
+	for e := 0; e < benchEntities; e++ {
+		p.set(e, position{})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for e := 0; e < benchEntities; e++ {
+			c := p.get(e).(*position)
+			c.X++
+		}
+	}
+}
+
+// Sparse ids: only every 10th entity has the component.
+func BenchmarkPoolIterateSparse(b *testing.B) {
+	p := NewPool[position](0)
+	for e := 0; e < benchEntities; e += 10 {
+		p.Set(e, position{})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		data := p.Data()
+		for j := range data {
+			data[j].X++
+		}
+	}
+}
+
+func BenchmarkReflectPoolIterateSparse(b *testing.B) {
+	p := &reflectPool{}
+	for e := 0; e < benchEntities; e += 10 {
+		p.set(e, position{})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for e := 0; e < benchEntities; e += 10 {
+			c := p.get(e).(*position)
+			c.X++
+		}
+	}
+}
+
+func BenchmarkRegistryGet(b *testing.B) {
+	r := newTestRegistry()
+	entities := make([]Entity, benchEntities)
+	for i := range entities {
+		entities[i] = r.CreateEntity()
+		Add(r, entities[i], position{})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, e := range entities {
+			c, _ := Get[position](r, e)
+			c.X++
+		}
+	}
+}
+
+// BenchmarkRegistryGetReflect reproduces the removed GetComponentPtr: a type
+// registry lookup to find the pool, then a reflect index into it.
+func BenchmarkRegistryGetReflect(b *testing.B) {
+	types := NewTypeRegistry(32)
+	componentPools := make([]*reflectPool, 32)
+	componentID, _ := types.Register(position{})
+	componentPools[componentID] = &reflectPool{}
+	for e := 0; e < benchEntities; e++ {
+		componentPools[componentID].set(e, position{})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for e := 0; e < benchEntities; e++ {
+			componentID := types.Getx(position{})
+			c := componentPools[componentID].get(e).(*position)
+			c.X++
+		}
+	}
+}
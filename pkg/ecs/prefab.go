@@ -0,0 +1,252 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrPrefabNotFound = errors.New("prefab not found")
+	ErrPrefabCycle    = errors.New("prefab inheritance cycle")
+)
+
+// prefab is an entity template. Components are keyed by codec name, see
+// RegisterCodec, and hold their field values as JSON.
+type prefab struct {
+	Extends    string                     `json:"extends"`
+	Tags       []string                   `json:"tags"`
+	Components map[string]json.RawMessage `json:"components"`
+	// components and tags merged with the ones of the prefabs it extends
+	resolved *resolvedPrefab
+}
+
+type resolvedPrefab struct {
+	tags       []string
+	components map[string]json.RawMessage
+}
+
+// PrefabOverride changes a component of an entity spawned from a prefab.
+type PrefabOverride func(r *Registry, entity Entity) error
+
+// Override adds component to the spawned entity, replacing the prefab's.
+func Override[T any](component T) PrefabOverride {
+	return func(r *Registry, entity Entity) error {
+		return Add(r, entity, component)
+	}
+}
+
+// Modify calls fn with the T component of the spawned entity.
+func Modify[T any](fn func(component *T)) PrefabOverride {
+	return func(r *Registry, entity Entity) error {
+		component, err := Get[T](r, entity)
+		if err != nil {
+			return fmt.Errorf("%T: %w", *new(T), err)
+		}
+		fn(component)
+		return nil
+	}
+}
+
+// LoadPrefabs reads prefabs from a JSON object mapping names to prefabs:
+//
+//	{
+//		"tank": {"components": {"transform": {"Scale": {"X": 1, "Y": 1}}}},
+//		"heavy_tank": {"extends": "tank", "components": {"health": {"HP": 200}}}
+//	}
+//
+// A prefab that extends another one gets its tags and components, fields it
+// sets replace the inherited ones. Prefabs with the same name as a loaded one
+// replace it. Prefabs are checked when loaded, so the prefabs they extend must
+// be loaded first. When a prefab is invalid none of them are loaded.
+func (r *Registry) LoadPrefabs(rd io.Reader) error {
+	loaded := make(map[string]*prefab)
+	if err := json.NewDecoder(rd).Decode(&loaded); err != nil {
+		return fmt.Errorf("prefabs: %w", err)
+	}
+	// Every prefab is resolved again in a copy, one may extend a replaced
+	// prefab, and the copy is kept only if they are all valid
+	prefabs := maps.Clone(r.prefabs)
+	maps.Copy(prefabs, loaded)
+	for name, p := range prefabs {
+		unresolved := *p
+		unresolved.resolved = nil
+		prefabs[name] = &unresolved
+	}
+	for name := range prefabs {
+		if _, err := resolvePrefab(r, prefabs, name, nil); err != nil {
+			return err
+		}
+	}
+	r.prefabs = prefabs
+	return nil
+}
+
+func (r *Registry) LoadPrefabFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := r.LoadPrefabs(file); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func (r *Registry) HasPrefab(name string) bool {
+	_, exists := r.prefabs[name]
+	return exists
+}
+
+// Spawn creates an entity from the prefab name and applies overrides to it.
+// The entity is killed again when a component or override fails.
+func (r *Registry) Spawn(name string, overrides ...PrefabOverride) (Entity, error) {
+	p, err := resolvePrefab(r, r.prefabs, name, nil)
+	if err != nil {
+		return Entity{}, err
+	}
+	entity := r.CreateEntity()
+	if err := r.instantiate(entity, name, p, overrides); err != nil {
+		r.KillEntity(entity)
+		return Entity{}, err
+	}
+	return entity, nil
+}
+
+// SpawnPrefab records spawning the prefab name, see Registry.Spawn. Like
+// Spawn, it returns a placeholder for the entity.
+func (c *Commands) SpawnPrefab(name string, overrides ...PrefabOverride) Entity {
+	return c.reserve(func(r *Registry, spawned *placeholders) (Entity, error) {
+		return r.Spawn(name, overrides...)
+	})
+}
+
+func (r *Registry) instantiate(entity Entity, name string, p *resolvedPrefab, overrides []PrefabOverride) error {
+	for _, tag := range p.tags {
+		r.AddTag(entity, tag)
+	}
+	names := make([]string, 0, len(p.components))
+	for componentName := range p.components {
+		names = append(names, componentName)
+	}
+	slices.Sort(names)
+	for _, componentName := range names {
+		cd := r.codecs.byName[componentName]
+		component, err := cd.decode(func(v any) error {
+			return json.Unmarshal(p.components[componentName], v)
+		})
+		if err == nil {
+			err = cd.add(r, entity, component)
+		}
+		if err != nil {
+			return fmt.Errorf("prefab %q: %s: %w", name, componentName, err)
+		}
+	}
+	for _, override := range overrides {
+		if err := override(r, entity); err != nil {
+			return fmt.Errorf("prefab %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// resolvePrefab returns the components of the prefab name merged with the
+// ones it inherits from prefabs. chain holds the prefabs being resolved to
+// detect cycles.
+func resolvePrefab(r *Registry, prefabs map[string]*prefab, name string, chain []string) (*resolvedPrefab, error) {
+	p, exists := prefabs[name]
+	if !exists {
+		if len(chain) > 0 {
+			return nil, fmt.Errorf("%w: %q extended by %q", ErrPrefabNotFound, name, chain[len(chain)-1])
+		}
+		return nil, fmt.Errorf("%w: %q", ErrPrefabNotFound, name)
+	}
+	if p.resolved != nil {
+		return p.resolved, nil
+	}
+	if slices.Contains(chain, name) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrPrefabCycle, strings.Join(chain, " -> "), name)
+	}
+	for componentName := range p.Components {
+		if _, exists := r.codecs.byName[componentName]; !exists {
+			return nil, fmt.Errorf("prefab %q: %w %q", name, ErrUnknownComponent, componentName)
+		}
+	}
+
+	resolved := &resolvedPrefab{
+		tags:       make([]string, 0),
+		components: make(map[string]json.RawMessage),
+	}
+	if p.Extends != "" {
+		inherited, err := resolvePrefab(r, prefabs, p.Extends, append(chain, name))
+		if err != nil {
+			return nil, err
+		}
+		resolved.tags = append(resolved.tags, inherited.tags...)
+		for componentName, data := range inherited.components {
+			resolved.components[componentName] = data
+		}
+	}
+	for _, tag := range p.Tags {
+		if !slices.Contains(resolved.tags, tag) {
+			resolved.tags = append(resolved.tags, tag)
+		}
+	}
+	for componentName, data := range p.Components {
+		merged, err := mergeJSON(resolved.components[componentName], data)
+		if err != nil {
+			return nil, fmt.Errorf("prefab %q: %s: %w", name, componentName, err)
+		}
+		resolved.components[componentName] = merged
+	}
+	p.resolved = resolved
+	return resolved, nil
+}
+
+// mergeJSON returns override with the fields it doesn't set taken from base.
+// Objects are merged recursively, any other value replaces the base one.
+func mergeJSON(base, override json.RawMessage) (json.RawMessage, error) {
+	if base == nil {
+		return override, nil
+	}
+	baseValue, err := decodeJSONValue(base)
+	if err != nil {
+		return nil, err
+	}
+	overrideValue, err := decodeJSONValue(override)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergeValues(baseValue, overrideValue))
+}
+
+// decodeJSONValue keeps numbers as json.Number, float64 would round integers
+// above 2^53.
+func decodeJSONValue(data json.RawMessage) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func mergeValues(base, override any) any {
+	baseObject, baseOk := base.(map[string]any)
+	overrideObject, overrideOk := override.(map[string]any)
+	if !baseOk || !overrideOk {
+		return override
+	}
+	for key, value := range overrideObject {
+		baseObject[key] = mergeValues(baseObject[key], value)
+	}
+	return baseObject
+}
@@ -0,0 +1,108 @@
+package ecs
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testPrefabs = `{
+	"mover": {"components": {"position": {"X": 1, "Y": 2}, "velocity": {"X": 5}}},
+	"fast_mover": {"extends": "mover", "components": {"velocity": {"Y": 9}}}
+}`
+
+func TestSpawnPrefab(t *testing.T) {
+	r := newSnapshotRegistry()
+	if err := r.LoadPrefabs(strings.NewReader(testPrefabs)); err != nil {
+		t.Fatalf("Unexpected error loading prefabs: %v", err)
+	}
+
+	e, err := r.Spawn("fast_mover", Modify(func(p *position) { p.Y = 20 }))
+	if err != nil {
+		t.Fatalf("Unexpected error spawning: %v", err)
+	}
+	if p, _ := Get[position](r, e); p == nil || *p != (position{X: 1, Y: 20}) {
+		t.Errorf("Expected inherited and modified position, got %+v", p)
+	}
+	if v, _ := Get[velocity](r, e); v == nil || *v != (velocity{X: 5, Y: 9}) {
+		t.Errorf("Expected velocity fields to be merged, got %+v", v)
+	}
+
+	e, _ = r.Spawn("mover", Override(velocity{}), Override(frozen{}))
+	if v, _ := Get[velocity](r, e); *v != (velocity{}) || !Has[frozen](r, e) {
+		t.Errorf("Expected overrides to replace and add components, got %+v", v)
+	}
+	if _, err := r.Spawn("missing"); !errors.Is(err, ErrPrefabNotFound) {
+		t.Errorf("Expected ErrPrefabNotFound, got %v", err)
+	}
+	if _, err := r.Spawn("mover", Modify(func(f *frozen) {})); !errors.Is(err, ErrComponentNotFound) {
+		t.Errorf("Expected ErrComponentNotFound, got %v", err)
+	}
+}
+
+func TestLoadPrefabsErrors(t *testing.T) {
+	tests := map[string]struct {
+		prefabs string
+		err     error
+	}{
+		"unknown component": {`{"a": {"components": {"pos": {}}}}`, ErrUnknownComponent},
+		"missing parent":    {`{"a": {"extends": "b"}}`, ErrPrefabNotFound},
+		"cycle":             {`{"a": {"extends": "b"}, "b": {"extends": "a"}}`, ErrPrefabCycle},
+	}
+	for name, test := range tests {
+		r := newSnapshotRegistry()
+		if err := r.LoadPrefabs(strings.NewReader(test.prefabs)); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, got %v", name, test.err, err)
+		}
+	}
+}
+
+func TestMergeJSONKeepsLargeIntegers(t *testing.T) {
+	merged, err := mergeJSON(json.RawMessage(`{"N": 1, "M": 2}`), json.RawMessage(`{"N": 9007199254740993}`))
+	if err != nil {
+		t.Fatalf("Unexpected error merging: %v", err)
+	}
+	if want := `{"M":2,"N":9007199254740993}`; string(merged) != want {
+		t.Errorf("Expected %s, got %s", want, merged)
+	}
+}
+
+func TestLoadPrefabsIsAtomic(t *testing.T) {
+	r := newSnapshotRegistry()
+	if err := r.LoadPrefabs(strings.NewReader(testPrefabs)); err != nil {
+		t.Fatalf("Unexpected error loading prefabs: %v", err)
+	}
+	invalid := `{
+		"mover": {"components": {"velocity": {"X": 1}}},
+		"stopper": {"components": {"velocity": {}}},
+		"broken": {"components": {"pos": {}}}
+	}`
+	if err := r.LoadPrefabs(strings.NewReader(invalid)); !errors.Is(err, ErrUnknownComponent) {
+		t.Fatalf("Expected ErrUnknownComponent, got %v", err)
+	}
+	if r.HasPrefab("stopper") || r.HasPrefab("broken") {
+		t.Error("Expected no prefab of a failed load to be kept")
+	}
+	e, err := r.Spawn("fast_mover")
+	if err != nil {
+		t.Fatalf("Unexpected error spawning: %v", err)
+	}
+	if p, _ := Get[position](r, e); p == nil || *p != (position{X: 1, Y: 2}) {
+		t.Errorf("Expected the replaced prefab to be kept after a failed load, got %+v", p)
+	}
+}
+
+func TestCommandsSpawnPrefab(t *testing.T) {
+	r := newSnapshotRegistry()
+	r.LoadPrefabs(strings.NewReader(testPrefabs))
+	cmds := r.Commands()
+	e := cmds.SpawnPrefab("mover")
+	AddDeferred(cmds, e, frozen{})
+	r.Update()
+
+	q, _ := NewQuery(r, With[position](), With[frozen]())
+	if q.Len() != 1 {
+		t.Errorf("Expected the spawned prefab to get the deferred component, got %v", q.Entities())
+	}
+}
@@ -0,0 +1,311 @@
+package ecs
+
+import (
+	"iter"
+
+	"github.com/kubil6y/go_game_engine/pkg/bitset"
+)
+
+// Query is the set of entities that have every required component and none
+// of the excluded ones. Optional components don't affect matching; iterating
+// them yields nil for entities that don't have them.
+//
+// Added and Changed filters only apply when iterating: All and the Iter
+// functions skip entities whose components didn't change in the previous
+// frame, Entities and Len don't.
+//
+// Queries are kept up to date by the Registry on every Update, so reading
+// their entities doesn't scan the world.
+type Query struct {
+	registry *Registry
+	with     *bitset.BitsetN
+	without  *bitset.BitsetN
+	optional *bitset.BitsetN
+	added    *bitset.BitsetN
+	changed  *bitset.BitsetN
+	filters  []changeFilter
+	entities []Entity
+	// [key = entity] [value = index in entities]
+	indices map[Entity]int
+}
+
+type queryKey struct {
+	with, without, optional, added, changed string
+}
+
+type changeFilter struct {
+	componentID ComponentTypeID
+	added       bool
+}
+
+// QueryTerm adds a component filter to a query.
+type QueryTerm func(r *Registry, q *Query) error
+
+func With[T any]() QueryTerm {
+	return func(r *Registry, q *Query) error {
+		componentID, err := ComponentID[T](r)
+		if err != nil {
+			return err
+		}
+		q.with.Set(int(componentID))
+		return nil
+	}
+}
+
+func Without[T any]() QueryTerm {
+	return func(r *Registry, q *Query) error {
+		componentID, err := ComponentID[T](r)
+		if err != nil {
+			return err
+		}
+		q.without.Set(int(componentID))
+		return nil
+	}
+}
+
+func Optional[T any]() QueryTerm {
+	return func(r *Registry, q *Query) error {
+		componentID, err := ComponentID[T](r)
+		if err != nil {
+			return err
+		}
+		q.optional.Set(int(componentID))
+		return nil
+	}
+}
+
+// Added matches entities that have a T component which was added in the
+// previous frame, between the last two calls to Registry.Update.
+func Added[T any]() QueryTerm {
+	return changeTerm[T](true)
+}
+
+// Changed matches entities that have a T component which was added, replaced
+// or flagged by MarkChanged in the previous frame.
+func Changed[T any]() QueryTerm {
+	return changeTerm[T](false)
+}
+
+func changeTerm[T any](added bool) QueryTerm {
+	return func(r *Registry, q *Query) error {
+		componentID, err := ComponentID[T](r)
+		if err != nil {
+			return err
+		}
+		q.with.Set(int(componentID))
+		if added {
+			q.added.Set(int(componentID))
+		} else {
+			q.changed.Set(int(componentID))
+		}
+		q.filters = append(q.filters, changeFilter{componentID: componentID, added: added})
+		return nil
+	}
+}
+
+func newQuery(r *Registry) *Query {
+	return &Query{
+		registry: r,
+		with:     bitset.NewBitsetN(r.maxComponentCount),
+		without:  bitset.NewBitsetN(r.maxComponentCount),
+		optional: bitset.NewBitsetN(r.maxComponentCount),
+		added:    bitset.NewBitsetN(r.maxComponentCount),
+		changed:  bitset.NewBitsetN(r.maxComponentCount),
+		filters:  make([]changeFilter, 0),
+		entities: make([]Entity, 0),
+		indices:  make(map[Entity]int),
+	}
+}
+
+// NewQuery returns the query matching terms. Queries are cached, asking for
+// the same terms twice returns the same query.
+func NewQuery(r *Registry, terms ...QueryTerm) (*Query, error) {
+	q := newQuery(r)
+	for _, term := range terms {
+		if err := term(r, q); err != nil {
+			return nil, err
+		}
+	}
+	key := q.key()
+	if cached, exists := r.queries[key]; exists {
+		return cached, nil
+	}
+	for _, entity := range r.liveEntities() {
+		q.update(entity, r.entityComponentSignatures[entity.GetID()])
+	}
+	r.queries[key] = q
+	return q, nil
+}
+
+func (q *Query) key() queryKey {
+	return queryKey{
+		with:     q.with.String(),
+		without:  q.without.String(),
+		optional: q.optional.String(),
+		added:    q.added.String(),
+		changed:  q.changed.String(),
+	}
+}
+
+func (q *Query) Matches(signature *bitset.BitsetN) bool {
+	return signature.Contains(q.with) && !signature.Intersects(q.without)
+}
+
+func (q *Query) Has(entity Entity) bool {
+	_, exists := q.indices[entity]
+	return exists
+}
+
+func (q *Query) Len() int {
+	return len(q.entities)
+}
+
+// Entities returns the matching entities. The slice is owned by the query and
+// changes on the next Registry.Update.
+func (q *Query) Entities() []Entity {
+	return q.entities
+}
+
+func (q *Query) All() iter.Seq[Entity] {
+	return func(yield func(Entity) bool) {
+		for _, entity := range q.entities {
+			if !q.passes(entity) {
+				continue
+			}
+			if !yield(entity) {
+				return
+			}
+		}
+	}
+}
+
+// passes reports whether entity satisfies the Added and Changed filters.
+func (q *Query) passes(entity Entity) bool {
+	for _, filter := range q.filters {
+		pool := q.registry.getPool(filter.componentID)
+		if pool == nil {
+			return false
+		}
+		ticks, exists := pool.changeTicks(entity.GetID())
+		tick := ticks.changed
+		if filter.added {
+			tick = ticks.added
+		}
+		if !exists || tick != q.registry.tick-1 {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *Query) add(entity Entity) bool {
+	if q.Has(entity) {
+		return false
+	}
+	q.indices[entity] = len(q.entities)
+	q.entities = append(q.entities, entity)
+	return true
+}
+
+func (q *Query) remove(entity Entity) bool {
+	index, exists := q.indices[entity]
+	if !exists {
+		return false
+	}
+	last := len(q.entities) - 1
+	q.entities[index] = q.entities[last]
+	q.indices[q.entities[index]] = index
+	q.entities = q.entities[:last]
+	delete(q.indices, entity)
+	return true
+}
+
+func (q *Query) update(entity Entity, signature *bitset.BitsetN) {
+	if q.Matches(signature) {
+		q.add(entity)
+	} else {
+		q.remove(entity)
+	}
+}
+
+// ITERATION ////////////////////
+type Row2[A, B any] struct {
+	A *A
+	B *B
+}
+
+type Row3[A, B, C any] struct {
+	A *A
+	B *B
+	C *C
+}
+
+// column is the storage of one iterated component type. Entities missing a
+// required column were changed since the last Update and are skipped.
+type column[T any] struct {
+	pool     *Pool[T]
+	required bool
+}
+
+func columnOf[T any](q *Query) column[T] {
+	componentID := typeID[T](q.registry)
+	return column[T]{
+		pool:     typedPool[T](q.registry),
+		required: componentID != -1 && q.with.IsSet(componentID),
+	}
+}
+
+func (c column[T]) fetch(entity Entity) (*T, bool) {
+	component := fetch(c.pool, entity)
+	return component, component != nil || !c.required
+}
+
+// Iter yields every entity of q together with its A component. A is nil for
+// entities that don't have it, which only happens when A is not required.
+func Iter[A any](q *Query) iter.Seq2[Entity, *A] {
+	return func(yield func(Entity, *A) bool) {
+		ca := columnOf[A](q)
+		for _, entity := range q.entities {
+			a, ok := ca.fetch(entity)
+			if !ok || !q.passes(entity) {
+				continue
+			}
+			if !yield(entity, a) {
+				return
+			}
+		}
+	}
+}
+
+func Iter2[A, B any](q *Query) iter.Seq2[Entity, Row2[A, B]] {
+	return func(yield func(Entity, Row2[A, B]) bool) {
+		ca, cb := columnOf[A](q), columnOf[B](q)
+		for _, entity := range q.entities {
+			a, okA := ca.fetch(entity)
+			b, okB := cb.fetch(entity)
+			if !okA || !okB || !q.passes(entity) {
+				continue
+			}
+			if !yield(entity, Row2[A, B]{A: a, B: b}) {
+				return
+			}
+		}
+	}
+}
+
+func Iter3[A, B, C any](q *Query) iter.Seq2[Entity, Row3[A, B, C]] {
+	return func(yield func(Entity, Row3[A, B, C]) bool) {
+		ca, cb, cc := columnOf[A](q), columnOf[B](q), columnOf[C](q)
+		for _, entity := range q.entities {
+			a, okA := ca.fetch(entity)
+			b, okB := cb.fetch(entity)
+			c, okC := cc.fetch(entity)
+			if !okA || !okB || !okC || !q.passes(entity) {
+				continue
+			}
+			if !yield(entity, Row3[A, B, C]{A: a, B: b, C: c}) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package ecs
+
+import "testing"
+
+type frozen struct{}
+
+func TestQueryFilters(t *testing.T) {
+	r := newTestRegistry()
+	q, err := NewQuery(r, With[position](), Without[frozen](), Optional[velocity]())
+	if err != nil {
+		t.Fatalf("Unexpected error creating query: %v", err)
+	}
+
+	moving := r.CreateEntity()
+	Add(r, moving, position{})
+	Add(r, moving, velocity{X: 1})
+	still := r.CreateEntity()
+	Add(r, still, position{})
+	stuck := r.CreateEntity()
+	Add(r, stuck, position{})
+	Add(r, stuck, frozen{})
+	r.Update()
+
+	if q.Len() != 2 || !q.Has(moving) || !q.Has(still) || q.Has(stuck) {
+		t.Fatalf("Expected query to match moving and still, got %v", q.Entities())
+	}
+
+	seen := 0
+	for e, c := range Iter2[position, velocity](q) {
+		seen++
+		if c.A == nil {
+			t.Errorf("Expected required position for %s", e)
+		}
+		if e == moving && (c.B == nil || c.B.X != 1) {
+			t.Errorf("Expected optional velocity for %s, got %+v", e, c.B)
+		}
+		if e == still && c.B != nil {
+			t.Errorf("Expected nil optional velocity for %s, got %+v", e, c.B)
+		}
+	}
+	if seen != 2 {
+		t.Errorf("Expected to iterate 2 entities, got %d", seen)
+	}
+}
+
+func TestQueryIsUpdatedIncrementally(t *testing.T) {
+	r := newTestRegistry()
+	e := r.CreateEntity()
+	Add(r, e, position{})
+	r.Update()
+
+	q, _ := NewQuery(r, With[position](), Without[frozen]())
+	if !q.Has(e) {
+		t.Fatalf("Expected query to be populated with existing %s", e)
+	}
+
+	Add(r, e, frozen{})
+	r.Update()
+	if q.Has(e) {
+		t.Errorf("Expected %s to leave the query after frozen was added", e)
+	}
+
+	Remove[frozen](r, e)
+	r.Update()
+	if !q.Has(e) {
+		t.Errorf("Expected %s to rejoin the query after frozen was removed", e)
+	}
+
+	r.KillEntity(e)
+	r.Update()
+	if q.Len() != 0 {
+		t.Errorf("Expected killed %s to leave the query, got %v", e, q.Entities())
+	}
+}
+
+func TestQueryIsCached(t *testing.T) {
+	r := newTestRegistry()
+	a, _ := NewQuery(r, With[position](), With[velocity]())
+	b, _ := NewQuery(r, With[velocity](), With[position]())
+	if a != b {
+		t.Error("Expected queries with the same terms to be shared")
+	}
+	c, _ := NewQuery(r, With[position]())
+	if a == c {
+		t.Error("Expected queries with different terms to be distinct")
+	}
+}
+
+func TestIterSkipsEntitiesMissingRequiredComponents(t *testing.T) {
+	r := newTestRegistry()
+	e := r.CreateEntity()
+	Add(r, e, position{})
+	r.Update()
+	q, _ := NewQuery(r, With[position]())
+
+	Remove[position](r, e)
+	for e, p := range Iter[position](q) {
+		t.Errorf("Expected %s to be skipped before Update, got %+v", e, p)
+	}
+}
+
+func TestIterStopsEarly(t *testing.T) {
+	r := newTestRegistry()
+	for i := 0; i < 3; i++ {
+		Add(r, r.CreateEntity(), position{})
+	}
+	r.Update()
+	q, _ := NewQuery(r, With[position]())
+
+	seen := 0
+	for range Iter[position](q) {
+		seen++
+		break
+	}
+	if seen != 1 {
+		t.Errorf("Expected break to stop iteration, saw %d entities", seen)
+	}
+}
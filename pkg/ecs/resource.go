@@ -0,0 +1,56 @@
+package ecs
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+var (
+	ErrResourceNotFound = errors.New("resource not found")
+)
+
+// resources holds one value per type that isn't attached to any entity, like
+// the camera or the map bounds.
+type resources struct {
+	mu     sync.RWMutex
+	values map[reflect.Type]any
+}
+
+func newResources() *resources {
+	return &resources{
+		values: make(map[reflect.Type]any),
+	}
+}
+
+// SetResource stores resource as the registry's T resource, replacing the
+// previous one. Store a pointer for resources that systems modify.
+func SetResource[T any](r *Registry, resource T) {
+	r.resources.mu.Lock()
+	defer r.resources.mu.Unlock()
+	r.resources.values[reflect.TypeFor[T]()] = resource
+}
+
+func GetResource[T any](r *Registry) (T, error) {
+	r.resources.mu.RLock()
+	defer r.resources.mu.RUnlock()
+	resource, exists := r.resources.values[reflect.TypeFor[T]()]
+	if !exists {
+		var zero T
+		return zero, ErrResourceNotFound
+	}
+	return resource.(T), nil
+}
+
+func HasResource[T any](r *Registry) bool {
+	r.resources.mu.RLock()
+	defer r.resources.mu.RUnlock()
+	_, exists := r.resources.values[reflect.TypeFor[T]()]
+	return exists
+}
+
+func RemoveResource[T any](r *Registry) {
+	r.resources.mu.Lock()
+	defer r.resources.mu.Unlock()
+	delete(r.resources.values, reflect.TypeFor[T]())
+}
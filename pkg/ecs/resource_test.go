@@ -0,0 +1,42 @@
+package ecs
+
+import (
+	"errors"
+	"testing"
+)
+
+type gravity struct {
+	Y float32
+}
+
+func TestResources(t *testing.T) {
+	r := newTestRegistry()
+	if _, err := GetResource[gravity](r); !errors.Is(err, ErrResourceNotFound) {
+		t.Errorf("Expected ErrResourceNotFound, got %v", err)
+	}
+
+	SetResource(r, gravity{Y: 9.8})
+	SetResource(r, &position{X: 1})
+	g, err := GetResource[gravity](r)
+	if err != nil || g.Y != 9.8 {
+		t.Errorf("Expected gravity resource, got %+v (err=%v)", g, err)
+	}
+	if HasResource[position](r) {
+		t.Error("Expected position and *position to be different resources")
+	}
+
+	p, _ := GetResource[*position](r)
+	p.X = 2
+	if p, _ := GetResource[*position](r); p.X != 2 {
+		t.Errorf("Expected pointer resources to be shared, got %+v", p)
+	}
+
+	SetResource(r, gravity{Y: 1.6})
+	if g, _ := GetResource[gravity](r); g.Y != 1.6 {
+		t.Errorf("Expected SetResource to replace the resource, got %+v", g)
+	}
+	RemoveResource[gravity](r)
+	if HasResource[gravity](r) {
+		t.Error("Expected gravity resource to be removed")
+	}
+}
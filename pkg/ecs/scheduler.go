@@ -0,0 +1,351 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/kubil6y/go_game_engine/pkg/bitset"
+)
+
+var (
+	ErrScheduleCycle  = errors.New("system ordering cycle")
+	ErrSystemNotFound = errors.New("system not found")
+)
+
+// Stage is a named group of systems run together by Registry.RunStage.
+type Stage string
+
+const (
+	StagePreUpdate  Stage = "PreUpdate"
+	StageUpdate     Stage = "Update"
+	StagePostUpdate Stage = "PostUpdate"
+	StageRender     Stage = "Render"
+)
+
+type scheduledSystem struct {
+	stage   Stage
+	before  []SystemTypeID
+	after   []SystemTypeID
+	groups  []string
+	enabled bool
+	// nil until the system declares its component access
+	reads  *bitset.BitsetN
+	writes *bitset.BitsetN
+}
+
+type SystemOption func(r *Registry, s *scheduledSystem) error
+
+// InStage sets the stage a system runs in, StageUpdate by default.
+func InStage(stage Stage) SystemOption {
+	return func(r *Registry, s *scheduledSystem) error {
+		s.stage = stage
+		return nil
+	}
+}
+
+// Before makes the system run before systemID when both are in the same stage.
+func Before(systemID SystemTypeID) SystemOption {
+	return func(r *Registry, s *scheduledSystem) error {
+		s.before = append(s.before, systemID)
+		return nil
+	}
+}
+
+// After makes the system run after systemID when both are in the same stage.
+func After(systemID SystemTypeID) SystemOption {
+	return func(r *Registry, s *scheduledSystem) error {
+		s.after = append(s.after, systemID)
+		return nil
+	}
+}
+
+// InGroup adds the system to groups, which can be disabled together with
+// SetGroupEnabled.
+func InGroup(groups ...string) SystemOption {
+	return func(r *Registry, s *scheduledSystem) error {
+		s.groups = append(s.groups, groups...)
+		return nil
+	}
+}
+
+// Reads declares that the system reads T components. Systems that declare
+// their access may run concurrently with systems they don't conflict with,
+// systems that don't declare anything always run alone.
+//
+// Systems of a batch run on separate goroutines, so a system that declares
+// its access must record structural changes (creating entities, adding and
+// removing components) with Registry.Commands. Add and Remove fail with
+// ErrParallelChange inside a parallel batch, except for Add replacing a
+// component the entity already has.
+func Reads[T any]() SystemOption {
+	return func(r *Registry, s *scheduledSystem) error {
+		componentID, err := ComponentID[T](r)
+		if err != nil {
+			return err
+		}
+		s.declareAccess(r)
+		s.reads.Set(int(componentID))
+		return nil
+	}
+}
+
+// Writes declares that the system modifies T components.
+func Writes[T any]() SystemOption {
+	return func(r *Registry, s *scheduledSystem) error {
+		componentID, err := ComponentID[T](r)
+		if err != nil {
+			return err
+		}
+		s.declareAccess(r)
+		s.writes.Set(int(componentID))
+		return nil
+	}
+}
+
+func (s *scheduledSystem) declareAccess(r *Registry) {
+	if s.reads == nil {
+		s.reads = bitset.NewBitsetN(r.maxComponentCount)
+		s.writes = bitset.NewBitsetN(r.maxComponentCount)
+	}
+}
+
+func (s *scheduledSystem) exclusive() bool {
+	return s.reads == nil
+}
+
+// conflicts reports whether s and other can't run at the same time.
+func (s *scheduledSystem) conflicts(other *scheduledSystem) bool {
+	if s.exclusive() || other.exclusive() {
+		return true
+	}
+	return s.writes.Intersects(other.writes) ||
+		s.writes.Intersects(other.reads) ||
+		other.writes.Intersects(s.reads)
+}
+
+func (s *scheduledSystem) constrains(systemID SystemTypeID) bool {
+	return slices.Contains(s.before, systemID) || slices.Contains(s.after, systemID)
+}
+
+// SetSystemEnabled enables or disables a system. Disabled systems are skipped
+// by RunStage but their entities are kept up to date, so enabling them again
+// takes effect immediately.
+func (r *Registry) SetSystemEnabled(systemID SystemTypeID, enabled bool) error {
+	s, exists := r.schedule[systemID]
+	if !exists {
+		return fmt.Errorf("%w: %d", ErrSystemNotFound, systemID)
+	}
+	s.enabled = enabled
+	return nil
+}
+
+// SetGroupEnabled enables or disables every system of group. A system runs
+// when it is enabled and none of its groups are disabled.
+func (r *Registry) SetGroupEnabled(group string, enabled bool) {
+	if enabled {
+		r.disabledGroups.Remove(group)
+	} else {
+		r.disabledGroups.Add(group)
+	}
+}
+
+func (r *Registry) IsGroupEnabled(group string) bool {
+	return !r.disabledGroups.Contains(group)
+}
+
+// IsSystemEnabled reports whether RunStage runs the system, taking its groups
+// into account.
+func (r *Registry) IsSystemEnabled(systemID SystemTypeID) bool {
+	s, exists := r.schedule[systemID]
+	if !exists || !s.enabled {
+		return false
+	}
+	for _, group := range s.groups {
+		if r.disabledGroups.Contains(group) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetWorkers sets how many systems of a stage may run at the same time.
+// The default of 1 runs every system on the calling goroutine.
+func (r *Registry) SetWorkers(n int) {
+	r.workers = max(n, 1)
+}
+
+// RunStage updates every enabled system of stage in schedule order. Systems
+// in the same batch don't conflict and run concurrently when workers allow it.
+func (r *Registry) RunStage(stage Stage, dt float32) error {
+	if r.scheduleDirty {
+		if err := r.BuildSchedule(); err != nil {
+			return err
+		}
+	}
+	for _, batch := range r.batches[stage] {
+		batch = r.enabledSystems(batch)
+		if len(batch) == 0 {
+			continue
+		}
+		if len(batch) == 1 || r.workers == 1 {
+			for _, systemID := range batch {
+				r.systems[systemID].Update(dt)
+			}
+			continue
+		}
+		r.runBatch(batch, dt)
+	}
+	return nil
+}
+
+// enabledSystems returns the enabled systems of batch, batch itself when all
+// of them are.
+func (r *Registry) enabledSystems(batch []SystemTypeID) []SystemTypeID {
+	for i, systemID := range batch {
+		if !r.IsSystemEnabled(systemID) {
+			enabled := slices.Clone(batch[:i])
+			for _, other := range batch[i+1:] {
+				if r.IsSystemEnabled(other) {
+					enabled = append(enabled, other)
+				}
+			}
+			return enabled
+		}
+	}
+	return batch
+}
+
+func (r *Registry) runBatch(batch []SystemTypeID, dt float32) {
+	r.parallel.Store(true)
+	defer r.parallel.Store(false)
+	var next atomic.Int32
+	var wg sync.WaitGroup
+	workers := min(r.workers, len(batch))
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				index := int(next.Add(1)) - 1
+				if index >= len(batch) {
+					return
+				}
+				r.systems[batch[index]].Update(dt)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// BuildSchedule orders the systems of every stage by their Before/After
+// constraints. Systems without constraints between them run in ascending id
+// order. It is called by RunStage when systems changed, calling it directly
+// reports ordering cycles early.
+func (r *Registry) BuildSchedule() error {
+	stages := make(map[Stage][]SystemTypeID)
+	for systemID, s := range r.schedule {
+		stages[s.stage] = append(stages[s.stage], systemID)
+	}
+	batches := make(map[Stage][][]SystemTypeID, len(stages))
+	for stage, systemIDs := range stages {
+		ordered, err := r.sortStage(systemIDs)
+		if err != nil {
+			return fmt.Errorf("stage %s: %w", stage, err)
+		}
+		stages[stage] = ordered
+		batches[stage] = r.batchStage(ordered)
+	}
+	r.stages = stages
+	r.batches = batches
+	r.scheduleDirty = false
+	return nil
+}
+
+// batchStage splits ordered systems into consecutive batches of systems that
+// neither conflict nor have ordering constraints between them.
+func (r *Registry) batchStage(ordered []SystemTypeID) [][]SystemTypeID {
+	batches := make([][]SystemTypeID, 0)
+	current := make([]SystemTypeID, 0)
+	for _, systemID := range ordered {
+		s := r.schedule[systemID]
+		fits := true
+		for _, other := range current {
+			o := r.schedule[other]
+			if s.conflicts(o) || s.constrains(other) || o.constrains(systemID) {
+				fits = false
+				break
+			}
+		}
+		if !fits {
+			batches = append(batches, current)
+			current = make([]SystemTypeID, 0)
+		}
+		current = append(current, systemID)
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+func (r *Registry) sortStage(systemIDs []SystemTypeID) ([]SystemTypeID, error) {
+	inStage := make(map[SystemTypeID]bool, len(systemIDs))
+	for _, systemID := range systemIDs {
+		inStage[systemID] = true
+	}
+
+	// [key = system id] [value = systems that must run after it]
+	edges := make(map[SystemTypeID][]SystemTypeID)
+	inDegree := make(map[SystemTypeID]int, len(systemIDs))
+	addEdge := func(from, to SystemTypeID) {
+		if !inStage[from] || !inStage[to] || from == to {
+			return
+		}
+		edges[from] = append(edges[from], to)
+		inDegree[to]++
+	}
+	for _, systemID := range systemIDs {
+		for _, other := range r.schedule[systemID].before {
+			addEdge(systemID, other)
+		}
+		for _, other := range r.schedule[systemID].after {
+			addEdge(other, systemID)
+		}
+	}
+
+	ready := make([]SystemTypeID, 0, len(systemIDs))
+	for _, systemID := range systemIDs {
+		if inDegree[systemID] == 0 {
+			ready = append(ready, systemID)
+		}
+	}
+	ordered := make([]SystemTypeID, 0, len(systemIDs))
+	for len(ready) > 0 {
+		slices.Sort(ready)
+		next := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, next)
+		for _, other := range edges[next] {
+			inDegree[other]--
+			if inDegree[other] == 0 {
+				ready = append(ready, other)
+			}
+		}
+	}
+
+	if len(ordered) < len(systemIDs) {
+		names := make([]string, 0)
+		for _, systemID := range systemIDs {
+			if inDegree[systemID] > 0 {
+				names = append(names, r.systems[systemID].GetName())
+			}
+		}
+		slices.Sort(names)
+		return nil, fmt.Errorf("%w between %s", ErrScheduleCycle, strings.Join(names, ", "))
+	}
+	return ordered, nil
+}
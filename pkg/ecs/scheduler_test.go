@@ -0,0 +1,123 @@
+package ecs
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+type recordingSystem struct {
+	*BaseSystem
+	log *[]string
+}
+
+func newRecordingSystem(r *Registry, name string, log *[]string) *recordingSystem {
+	return &recordingSystem{
+		BaseSystem: NewBaseSystem(name, r.logger, r),
+		log:        log,
+	}
+}
+
+func (s *recordingSystem) Update(dt float32) {
+	*s.log = append(*s.log, s.Name)
+}
+
+func TestRunStageOrder(t *testing.T) {
+	r := newTestRegistry()
+	var log []string
+	r.AddSystem(0, newRecordingSystem(r, "render", &log), InStage(StageRender))
+	r.AddSystem(1, newRecordingSystem(r, "collision", &log), After(3))
+	r.AddSystem(2, newRecordingSystem(r, "camera", &log))
+	r.AddSystem(3, newRecordingSystem(r, "movement", &log), Before(2))
+
+	if err := r.RunStage(StageUpdate, 0); err != nil {
+		t.Fatalf("Unexpected error running stage: %v", err)
+	}
+	expected := []string{"movement", "collision", "camera"}
+	if !slices.Equal(log, expected) {
+		t.Errorf("Expected %v, got %v", expected, log)
+	}
+
+	log = log[:0]
+	r.RunStage(StageRender, 0)
+	if !slices.Equal(log, []string{"render"}) {
+		t.Errorf("Expected only the render stage to run, got %v", log)
+	}
+}
+
+func TestConstraintsAcrossStagesAreIgnored(t *testing.T) {
+	r := newTestRegistry()
+	var log []string
+	r.AddSystem(0, newRecordingSystem(r, "a", &log), After(1))
+	r.AddSystem(1, newRecordingSystem(r, "b", &log), InStage(StagePostUpdate), After(0))
+	if err := r.BuildSchedule(); err != nil {
+		t.Errorf("Expected no cycle across stages, got %v", err)
+	}
+}
+
+func TestScheduleCycle(t *testing.T) {
+	r := newTestRegistry()
+	var log []string
+	r.AddSystem(0, newRecordingSystem(r, "a", &log), After(2))
+	r.AddSystem(1, newRecordingSystem(r, "b", &log), After(0))
+	r.AddSystem(2, newRecordingSystem(r, "c", &log), After(1))
+	r.AddSystem(3, newRecordingSystem(r, "d", &log))
+
+	err := r.BuildSchedule()
+	if !errors.Is(err, ErrScheduleCycle) {
+		t.Fatalf("Expected ErrScheduleCycle, got %v", err)
+	}
+	if err := r.RunStage(StageUpdate, 0); !errors.Is(err, ErrScheduleCycle) {
+		t.Errorf("Expected RunStage to report the cycle, got %v", err)
+	}
+	if len(log) != 0 {
+		t.Errorf("Expected no system to run, got %v", log)
+	}
+
+	r.RemoveSystem(1)
+	if err := r.RunStage(StageUpdate, 0); err != nil {
+		t.Errorf("Expected the cycle to be gone after removing a system, got %v", err)
+	}
+}
+
+func TestDisabledSystems(t *testing.T) {
+	r := newTestRegistry()
+	r.SetWorkers(4)
+	var log []string
+	movement := newRecordingSystem(r, "movement", &log)
+	RequireComponent[position](movement.BaseSystem)
+	r.AddSystem(0, movement, InGroup("simulation"))
+	r.AddSystem(1, newRecordingSystem(r, "spawner", &log), InGroup("simulation", "spawning"))
+	r.AddSystem(2, newRecordingSystem(r, "render", &log))
+	if err := r.SetSystemEnabled(9, false); !errors.Is(err, ErrSystemNotFound) {
+		t.Errorf("Expected ErrSystemNotFound, got %v", err)
+	}
+
+	run := func() []string {
+		log = log[:0]
+		r.RunStage(StageUpdate, 0)
+		return log
+	}
+	r.SetGroupEnabled("simulation", false)
+	if got := run(); !slices.Equal(got, []string{"render"}) {
+		t.Errorf("Expected the simulation group to be paused, got %v", got)
+	}
+
+	// membership is kept up to date while disabled
+	e := r.CreateEntity()
+	Add(r, e, position{})
+	r.Update()
+	if !movement.HasEntity(e) {
+		t.Error("Expected a disabled system to keep its entities up to date")
+	}
+
+	r.SetGroupEnabled("simulation", true)
+	r.SetGroupEnabled("spawning", false)
+	r.SetSystemEnabled(2, false)
+	if got := run(); !slices.Equal(got, []string{"movement"}) {
+		t.Errorf("Expected only movement to run, got %v", got)
+	}
+	if r.IsSystemEnabled(1) || !r.IsSystemEnabled(0) {
+		t.Error("Expected IsSystemEnabled to take groups into account")
+	}
+}
@@ -0,0 +1,348 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/kubil6y/go_game_engine/pkg/bitset"
+	"github.com/kubil6y/go_game_engine/pkg/set"
+)
+
+var (
+	ErrCodecNameTaken   = errors.New("codec name already taken")
+	ErrNoCodec          = errors.New("component has no codec")
+	ErrUnknownComponent = errors.New("unknown component")
+	ErrSnapshotVersion  = errors.New("unsupported snapshot version")
+	ErrSnapshotFormat   = errors.New("invalid snapshot format")
+	ErrRegistryNotEmpty = errors.New("registry is not empty")
+	ErrDuplicateEntity  = errors.New("entity appears twice in snapshot")
+)
+
+// SnapshotVersion is written to every snapshot, Load rejects other versions.
+const SnapshotVersion = 2
+
+type SnapshotFormat int
+
+const (
+	// SnapshotJSON is indented JSON, meant for debugging.
+	SnapshotJSON SnapshotFormat = iota
+	// SnapshotBinary is gob, meant for save files.
+	SnapshotBinary
+)
+
+var snapshotMagic = []byte("ECSS")
+
+// CODECS ////////////////////
+type codec struct {
+	name string
+	get  func(r *Registry, entity Entity) (any, error)
+	// decode returns a component filled by decode
+	decode func(decode func(v any) error) (any, error)
+	add    func(r *Registry, entity Entity, component any) error
+	// register gives the component type an id in r
+	register func(r *Registry) error
+}
+
+type codecs struct {
+	byName map[string]*codec
+	byType map[reflect.Type]*codec
+}
+
+func newCodecs() *codecs {
+	c := &codecs{
+		byName: make(map[string]*codec),
+		byType: make(map[reflect.Type]*codec),
+	}
+	registerCodec[Parent](c, "ecs.Parent")
+	registerCodec[Children](c, "ecs.Children")
+	return c
+}
+
+// RegisterCodec makes T components part of snapshots under name. Names are
+// written to snapshots, so renaming a component type doesn't break saves as
+// long as its name stays the same. Components are encoded with encoding/json
+// and encoding/gob and may implement their marshaling interfaces.
+func RegisterCodec[T any](r *Registry, name string) error {
+	return registerCodec[T](r.codecs, name)
+}
+
+func registerCodec[T any](c *codecs, name string) error {
+	componentType := reflect.TypeFor[T]()
+	if existing, exists := c.byName[name]; exists {
+		if c.byType[componentType] == existing {
+			return nil
+		}
+		return fmt.Errorf("%w: %q", ErrCodecNameTaken, name)
+	}
+	if existing, exists := c.byType[componentType]; exists {
+		return fmt.Errorf("%w: %s is registered as %q", ErrCodecNameTaken, componentType, existing.name)
+	}
+	cd := &codec{
+		name: name,
+		get: func(r *Registry, entity Entity) (any, error) {
+			return Get[T](r, entity)
+		},
+		decode: func(decode func(v any) error) (any, error) {
+			var component T
+			err := decode(&component)
+			return component, err
+		},
+		add: func(r *Registry, entity Entity, component any) error {
+			return Add(r, entity, component.(T))
+		},
+		register: func(r *Registry) error {
+			_, err := ComponentID[T](r)
+			return err
+		},
+	}
+	c.byName[name] = cd
+	c.byType[componentType] = cd
+	return nil
+}
+
+// SNAPSHOTS ////////////////////
+type snapshot[P any] struct {
+	Version int `json:"version"`
+	// [index = entity id - 1] the generation of every id ever used, so killed
+	// handles stay dead after Load
+	Generations []uint32 `json:"generations"`
+	// in the order they are reused
+	FreeIDs  []int               `json:"free_ids"`
+	Entities []snapshotEntity[P] `json:"entities"`
+}
+
+type snapshotEntity[P any] struct {
+	ID         int                    `json:"id"`
+	Generation uint32                 `json:"generation"`
+	Name       string                 `json:"name,omitempty"`
+	Tags       []string               `json:"tags,omitempty"`
+	Components []snapshotComponent[P] `json:"components"`
+}
+
+type snapshotComponent[P any] struct {
+	Name string `json:"name"`
+	Data P      `json:"data"`
+}
+
+// Save writes every live entity with its components to w. Changes queued
+// since the last Update are not applied first, call Save after Update.
+func (r *Registry) Save(w io.Writer, format SnapshotFormat) error {
+	switch format {
+	case SnapshotJSON:
+		s, err := buildSnapshot(r, func(v any) (json.RawMessage, error) {
+			return json.Marshal(v)
+		})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s)
+	case SnapshotBinary:
+		s, err := buildSnapshot(r, func(v any) ([]byte, error) {
+			var buf bytes.Buffer
+			err := gob.NewEncoder(&buf).Encode(v)
+			return buf.Bytes(), err
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(snapshotMagic); err != nil {
+			return err
+		}
+		return gob.NewEncoder(w).Encode(s)
+	}
+	return ErrSnapshotFormat
+}
+
+// Load restores the entities of a snapshot written by Save into r, which must
+// not have created any entity yet. Entities keep their ids and generations so
+// handles stored in components stay valid, and free ids keep theirs so
+// handles killed before Save stay dead. Entities join systems on the next
+// Update.
+func (r *Registry) Load(rd io.Reader, format SnapshotFormat) error {
+	if r.numEntities > 0 {
+		return ErrRegistryNotEmpty
+	}
+	switch format {
+	case SnapshotJSON:
+		var s snapshot[json.RawMessage]
+		if err := json.NewDecoder(rd).Decode(&s); err != nil {
+			return fmt.Errorf("%w: %w", ErrSnapshotFormat, err)
+		}
+		return restoreSnapshot(r, s, func(data json.RawMessage, v any) error {
+			return json.Unmarshal(data, v)
+		})
+	case SnapshotBinary:
+		magic := make([]byte, len(snapshotMagic))
+		if _, err := io.ReadFull(rd, magic); err != nil || !bytes.Equal(magic, snapshotMagic) {
+			return ErrSnapshotFormat
+		}
+		var s snapshot[[]byte]
+		if err := gob.NewDecoder(rd).Decode(&s); err != nil {
+			return fmt.Errorf("%w: %w", ErrSnapshotFormat, err)
+		}
+		return restoreSnapshot(r, s, func(data []byte, v any) error {
+			return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+		})
+	}
+	return ErrSnapshotFormat
+}
+
+func buildSnapshot[P any](r *Registry, encode func(v any) (P, error)) (snapshot[P], error) {
+	// [index = component id]
+	types := make([]reflect.Type, r.maxComponentCount)
+	for componentType, componentID := range r.componentTypes.GetTypeIDs() {
+		types[componentID] = componentType
+	}
+
+	s := snapshot[P]{
+		Version:     SnapshotVersion,
+		Generations: make([]uint32, r.numEntities),
+		FreeIDs:     make([]int, 0, r.freeIDs.Len()),
+		Entities:    make([]snapshotEntity[P], 0),
+	}
+	copy(s.Generations, r.entityGenerations[1:r.numEntities+1])
+	for e := r.freeIDs.Front(); e != nil; e = e.Next() {
+		s.FreeIDs = append(s.FreeIDs, e.Value.(int))
+	}
+	for _, entity := range r.liveEntities() {
+		e := snapshotEntity[P]{
+			ID:         entity.GetID(),
+			Generation: entity.Generation,
+			Name:       r.GetName(entity),
+			Tags:       r.GetTags(entity),
+			Components: make([]snapshotComponent[P], 0),
+		}
+		for componentID, componentType := range types {
+			if componentType == nil || !r.hasComponent(entity, ComponentTypeID(componentID)) {
+				continue
+			}
+			cd, exists := r.codecs.byType[componentType]
+			if !exists {
+				return s, fmt.Errorf("%w: %s", ErrNoCodec, componentType)
+			}
+			component, err := cd.get(r, entity)
+			if err != nil {
+				return s, fmt.Errorf("%s of %s: %w", cd.name, entity, err)
+			}
+			data, err := encode(component)
+			if err != nil {
+				return s, fmt.Errorf("%s of %s: %w", cd.name, entity, err)
+			}
+			e.Components = append(e.Components, snapshotComponent[P]{Name: cd.name, Data: data})
+		}
+		s.Entities = append(s.Entities, e)
+	}
+	return s, nil
+}
+
+func restoreSnapshot[P any](r *Registry, s snapshot[P], decode func(data P, v any) error) error {
+	if s.Version != SnapshotVersion {
+		return fmt.Errorf("%w: %d", ErrSnapshotVersion, s.Version)
+	}
+	// Everything that can fail is done before touching the entities of the
+	// registry, so a failed Load leaves it empty and can be retried
+	type stagedComponent struct {
+		codec     *codec
+		component any
+	}
+	entities := make([]Entity, 0, len(s.Entities))
+	staged := make([][]stagedComponent, 0, len(s.Entities))
+	names := make(map[string]Entity)
+	used := make(map[*codec]bool)
+	for _, e := range s.Entities {
+		entity := NewEntity(e.ID, e.Generation)
+		if owner, exists := names[e.Name]; exists && e.Name != "" {
+			return fmt.Errorf("%w: %q is %s and %s", ErrNameTaken, e.Name, owner, entity)
+		}
+		names[e.Name] = entity
+		components := make([]stagedComponent, 0, len(e.Components))
+		for _, c := range e.Components {
+			cd, exists := r.codecs.byName[c.Name]
+			if !exists {
+				return fmt.Errorf("%w %q on entity %d", ErrUnknownComponent, c.Name, e.ID)
+			}
+			component, err := cd.decode(func(v any) error {
+				return decode(c.Data, v)
+			})
+			if err != nil {
+				return fmt.Errorf("%s of %s: %w", c.Name, entity, err)
+			}
+			components = append(components, stagedComponent{codec: cd, component: component})
+			used[cd] = true
+		}
+		entities = append(entities, entity)
+		staged = append(staged, components)
+	}
+	for cd := range used {
+		if err := cd.register(r); err != nil {
+			return fmt.Errorf("%s: %w", cd.name, err)
+		}
+	}
+
+	if err := r.restoreEntities(entities, s.Generations, s.FreeIDs); err != nil {
+		return err
+	}
+	for i, e := range s.Entities {
+		if err := r.SetName(entities[i], e.Name); err != nil {
+			return err
+		}
+		for _, tag := range e.Tags {
+			r.AddTag(entities[i], tag)
+		}
+		for _, c := range staged[i] {
+			if err := c.codec.add(r, entities[i], c.component); err != nil {
+				return fmt.Errorf("%s of %s: %w", c.codec.name, entities[i], err)
+			}
+		}
+	}
+	return nil
+}
+
+// restoreEntities makes entities alive and frees freeIDs, every id up to
+// len(generations) must be one or the other. Ids keep their generations so
+// handles killed before the snapshot stay dead.
+func (r *Registry) restoreEntities(entities []Entity, generations []uint32, freeIDs []int) error {
+	numEntities := len(generations)
+	used := set.New[int]()
+	for _, entity := range entities {
+		entityID := entity.GetID()
+		if entityID <= 0 || entityID > numEntities || generations[entityID-1] != entity.Generation {
+			return fmt.Errorf("%w: %s", ErrSnapshotFormat, entity)
+		}
+		if used.Contains(entityID) {
+			return fmt.Errorf("%w: %s", ErrDuplicateEntity, entity)
+		}
+		used.Add(entityID)
+	}
+	for _, entityID := range freeIDs {
+		if entityID <= 0 || entityID > numEntities || used.Contains(entityID) {
+			return fmt.Errorf("%w: free id %d", ErrSnapshotFormat, entityID)
+		}
+		used.Add(entityID)
+	}
+	if used.Size() != numEntities {
+		return fmt.Errorf("%w: %d ids are neither alive nor free", ErrSnapshotFormat, numEntities-used.Size())
+	}
+
+	r.numEntities = numEntities
+	for r.numEntities >= len(r.entityComponentSignatures) {
+		r.growEntities()
+	}
+	copy(r.entityGenerations[1:], generations)
+	for _, entityID := range freeIDs {
+		r.freeIDs.PushBack(entityID)
+	}
+	for _, entity := range entities {
+		r.entityComponentSignatures[entity.GetID()] = bitset.NewBitsetN(r.maxComponentCount)
+		r.entityAlive[entity.GetID()] = true
+		r.queueSignatureChange(entity)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package ecs
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newSnapshotRegistry() *Registry {
+	r := newTestRegistry()
+	RegisterCodec[position](r, "position")
+	RegisterCodec[velocity](r, "velocity")
+	return r
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	for _, format := range []SnapshotFormat{SnapshotJSON, SnapshotBinary} {
+		r := newSnapshotRegistry()
+		parent := r.CreateEntity()
+		killed := r.CreateEntity()
+		child := r.CreateEntity()
+		Add(r, parent, position{X: 1, Y: 2})
+		Add(r, parent, velocity{X: 3})
+		Add(r, child, position{X: 4})
+		SetParent(r, child, parent)
+		r.KillEntity(killed)
+		r.Update()
+		reused := r.CreateEntity()
+		r.Update()
+
+		var buf bytes.Buffer
+		if err := r.Save(&buf, format); err != nil {
+			t.Fatalf("format %d: unexpected error saving: %v", format, err)
+		}
+		loaded := newSnapshotRegistry()
+		if err := loaded.Load(&buf, format); err != nil {
+			t.Fatalf("format %d: unexpected error loading: %v", format, err)
+		}
+		q, _ := NewQuery(loaded, With[position]())
+		loaded.Update()
+
+		for _, e := range []Entity{parent, child, reused} {
+			if !loaded.IsAlive(e) {
+				t.Errorf("format %d: expected %s to be alive", format, e)
+			}
+		}
+		if p, _ := Get[position](loaded, parent); p == nil || *p != (position{X: 1, Y: 2}) {
+			t.Errorf("format %d: expected parent position, got %+v", format, p)
+		}
+		if v, _ := Get[velocity](loaded, parent); v == nil || v.X != 3 {
+			t.Errorf("format %d: expected parent velocity, got %+v", format, v)
+		}
+		if p, ok := GetParent(loaded, child); !ok || p != parent {
+			t.Errorf("format %d: expected hierarchy to be restored, got %s", format, p)
+		}
+		if q.Len() != 2 {
+			t.Errorf("format %d: expected restored entities to join queries, got %v", format, q.Entities())
+		}
+		if next := loaded.CreateEntity(); next.GetID() != 4 {
+			t.Errorf("format %d: expected the next id to follow the snapshot, got %s", format, next)
+		}
+	}
+}
+
+func TestSnapshotKeepsKilledHandlesDead(t *testing.T) {
+	for _, format := range []SnapshotFormat{SnapshotJSON, SnapshotBinary} {
+		r := newSnapshotRegistry()
+		a := r.CreateEntity()
+		b := r.CreateEntity()
+		r.KillEntity(a)
+		r.Update()
+
+		var buf bytes.Buffer
+		if err := r.Save(&buf, format); err != nil {
+			t.Fatalf("format %d: unexpected error saving: %v", format, err)
+		}
+		loaded := newSnapshotRegistry()
+		if err := loaded.Load(&buf, format); err != nil {
+			t.Fatalf("format %d: unexpected error loading: %v", format, err)
+		}
+		loaded.Update()
+
+		created := loaded.CreateEntity()
+		if created == a || loaded.IsAlive(a) {
+			t.Errorf("format %d: expected killed %s to stay dead, created %s", format, a, created)
+		}
+		if created.GetID() != a.GetID() {
+			t.Errorf("format %d: expected the free id of %s to be reused, got %s", format, a, created)
+		}
+		if !loaded.IsAlive(b) {
+			t.Errorf("format %d: expected %s to be alive", format, b)
+		}
+	}
+}
+
+// failing cannot be decoded from JSON
+type failing struct{}
+
+func (f *failing) UnmarshalJSON(data []byte) error {
+	return errors.New("corrupt")
+}
+
+func TestFailedLoadLeavesRegistryEmpty(t *testing.T) {
+	data := `{"version": 2, "generations": [0, 0], "free_ids": [], "entities": [
+		{"id": 1, "generation": 0, "name": "first", "components": [{"name": "position", "data": {"X": 1}}]},
+		{"id": 2, "generation": 0, "components": [{"name": "failing", "data": {}}]}
+	]}`
+	r := newSnapshotRegistry()
+	RegisterCodec[failing](r, "failing")
+	if err := r.Load(strings.NewReader(data), SnapshotJSON); err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Fatalf("Expected the decode error, got %v", err)
+	}
+	if _, exists := r.FindByName("first"); exists || r.IsAlive(NewEntity(1, 0)) {
+		t.Error("Expected a failed Load to not restore any entity")
+	}
+	if pos, _ := GetPool[position](r); pos != nil && pos.Len() != 0 {
+		t.Errorf("Expected a failed Load to not restore any component, got %d", pos.Len())
+	}
+
+	retry := strings.Replace(data, `"failing"`, `"velocity"`, 1)
+	if err := r.Load(strings.NewReader(retry), SnapshotJSON); err != nil {
+		t.Fatalf("Expected Load to be retried after a failure, got %v", err)
+	}
+	if first, exists := r.FindByName("first"); !exists || !Has[position](r, first) {
+		t.Error("Expected the retried Load to restore the entities")
+	}
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	r := newTestRegistry()
+	Add(r, r.CreateEntity(), position{})
+	if err := r.Save(&bytes.Buffer{}, SnapshotJSON); !errors.Is(err, ErrNoCodec) {
+		t.Errorf("Expected ErrNoCodec, got %v", err)
+	}
+
+	r = newSnapshotRegistry()
+	if err := RegisterCodec[frozen](r, "position"); !errors.Is(err, ErrCodecNameTaken) {
+		t.Errorf("Expected ErrCodecNameTaken, got %v", err)
+	}
+	if err := RegisterCodec[position](r, "position"); err != nil {
+		t.Errorf("Expected registering the same codec twice to succeed, got %v", err)
+	}
+
+	renamed := `{"version": 2, "entities": [{"id": 1, "components": [{"name": "pos", "data": {}}]}]}`
+	err := newSnapshotRegistry().Load(strings.NewReader(renamed), SnapshotJSON)
+	if !errors.Is(err, ErrUnknownComponent) || !strings.Contains(err.Error(), `"pos"`) {
+		t.Errorf("Expected ErrUnknownComponent naming the component, got %v", err)
+	}
+
+	overlapping := `{"version": 2, "generations": [0], "free_ids": [1], "entities": [{"id": 1, "components": []}]}`
+	if err := newSnapshotRegistry().Load(strings.NewReader(overlapping), SnapshotJSON); !errors.Is(err, ErrSnapshotFormat) {
+		t.Errorf("Expected ErrSnapshotFormat for an id both alive and free, got %v", err)
+	}
+
+	future := `{"version": 99, "entities": []}`
+	if err := newSnapshotRegistry().Load(strings.NewReader(future), SnapshotJSON); !errors.Is(err, ErrSnapshotVersion) {
+		t.Errorf("Expected ErrSnapshotVersion, got %v", err)
+	}
+	if err := newSnapshotRegistry().Load(strings.NewReader("{}"), SnapshotBinary); !errors.Is(err, ErrSnapshotFormat) {
+		t.Errorf("Expected ErrSnapshotFormat, got %v", err)
+	}
+
+	r.CreateEntity()
+	if err := r.Load(strings.NewReader(future), SnapshotJSON); !errors.Is(err, ErrRegistryNotEmpty) {
+		t.Errorf("Expected ErrRegistryNotEmpty, got %v", err)
+	}
+}
@@ -1,9 +1,9 @@
 package ecs
 
 import (
+	"errors"
 	"fmt"
 
-	"github.com/kubil6y/go_game_engine/pkg/bitset"
 	"github.com/kubil6y/go_game_engine/pkg/logger"
 )
 
@@ -11,30 +11,42 @@ type System interface {
 	GetName() string
 	AddEntityToSystem(entity Entity)
 	RemoveEntityFromSystem(entity Entity)
+	HasEntity(entity Entity) bool
 	GetSystemEntities() []Entity
-	GetSignature() *bitset.Bitset32
+	GetQuery() *Query
 	Update(dt float32)
-    SubscribeToEvents()
+	SubscribeToEvents()
+	Err() error
+}
+
+// systemTeardown is implemented by systems that release resources, like
+// event subscriptions, when they are removed from the registry.
+type systemTeardown interface {
+	Teardown()
 }
 
 type BaseSystem struct {
-	Name               string
-	componentSignature *bitset.Bitset32
-	entities           []Entity
-	Logger             *logger.Logger
-	Registry           *Registry
+	Name      string
+	query     *Query
+	Logger    *logger.Logger
+	Registry  *Registry
+	err       error
+	teardowns []func()
 }
 
-func NewBaseSystem(name string, logger *logger.Logger, registry *Registry, bitset *bitset.Bitset32) *BaseSystem {
+func NewBaseSystem(name string, logger *logger.Logger, registry *Registry) *BaseSystem {
 	return &BaseSystem{
-		Name:               name,
-		componentSignature: bitset,
-		entities:           make([]Entity, 0),
-		Logger:             logger,
-		Registry:           registry,
+		Name:     name,
+		query:    newQuery(registry),
+		Logger:   logger,
+		Registry: registry,
 	}
 }
 
+func (s *BaseSystem) GetName() string {
+	return s.Name
+}
+
 func (s *BaseSystem) Update(dt float32) {
 	// This method is meant to be overridden by derived systems
 	s.Logger.Debug(fmt.Sprintf("Update method not implemented for %s", s.Name), nil)
@@ -46,27 +58,60 @@ func (s *BaseSystem) SubscribeToEvents() {
 }
 
 func (s *BaseSystem) AddEntityToSystem(entity Entity) {
-	s.entities = append(s.entities, entity)
-	s.Logger.Debug(fmt.Sprintf("Entity{%d} added to %s", entity.GetID(), s.Name), nil)
+	if s.query.add(entity) {
+		s.Logger.Debug(fmt.Sprintf("%s added to %s", entity, s.Name), nil)
+	}
 }
 
 func (s *BaseSystem) RemoveEntityFromSystem(entity Entity) {
-	index := -1
-	for i, e := range s.entities {
-		if e.GetID() == entity.GetID() {
-			index = i
-			break
-		}
-	}
-	if index != -1 {
-		s.entities = append(s.entities[:index], s.entities[index+1:]...)
-	}
+	s.query.remove(entity)
+}
+
+func (s *BaseSystem) HasEntity(entity Entity) bool {
+	return s.query.Has(entity)
 }
 
 func (s *BaseSystem) GetSystemEntities() []Entity {
-	return s.entities
+	return s.query.Entities()
 }
 
-func (s *BaseSystem) GetSignature() *bitset.Bitset32 {
-	return s.componentSignature
+// GetQuery returns the query that decides which entities belong to s.
+func (s *BaseSystem) GetQuery() *Query {
+	return s.query
+}
+
+func (s *BaseSystem) Err() error {
+	return s.err
+}
+
+// AddTeardown registers fn to run when s is removed from the registry, for
+// example the Unsubscribe of the subscriptions made in SubscribeToEvents.
+func (s *BaseSystem) AddTeardown(fn func()) {
+	s.teardowns = append(s.teardowns, fn)
+}
+
+// Teardown runs the functions registered with AddTeardown in reverse order,
+// RemoveSystem calls it.
+func (s *BaseSystem) Teardown() {
+	for i := len(s.teardowns) - 1; i >= 0; i-- {
+		s.teardowns[i]()
+	}
+	s.teardowns = nil
+}
+
+// RequireComponent restricts s to entities that have a T component. Errors
+// are kept on the system and reported when it is added to the registry.
+func RequireComponent[T any](s *BaseSystem) {
+	s.addQueryTerm(With[T]())
+}
+
+// ExcludeComponent restricts s to entities that don't have a T component.
+func ExcludeComponent[T any](s *BaseSystem) {
+	s.addQueryTerm(Without[T]())
+}
+
+func (s *BaseSystem) addQueryTerm(term QueryTerm) {
+	if err := term(s.Registry, s.query); err != nil {
+		s.err = errors.Join(s.err, err)
+	}
 }
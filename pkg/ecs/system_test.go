@@ -0,0 +1,97 @@
+package ecs
+
+import "testing"
+
+func newTestSystem(r *Registry) *BaseSystem {
+	s := NewBaseSystem("TestSystem", r.logger, r)
+	RequireComponent[position](s)
+	RequireComponent[velocity](s)
+	return s
+}
+
+func TestSystemJoinsWhenComponentAddedLater(t *testing.T) {
+	r := newTestRegistry()
+	s := newTestSystem(r)
+	r.AddSystem(0, s)
+
+	e := r.CreateEntity()
+	Add(r, e, position{})
+	r.Update()
+	if s.HasEntity(e) {
+		t.Fatalf("Expected %s to not match before velocity is added", e)
+	}
+
+	Add(r, e, velocity{})
+	if s.HasEntity(e) {
+		t.Errorf("Expected membership change to wait for Update")
+	}
+	r.Update()
+	if !s.HasEntity(e) || len(s.GetSystemEntities()) != 1 {
+		t.Errorf("Expected %s to join the system after Update, got %v", e, s.GetSystemEntities())
+	}
+}
+
+func TestSystemLeavesWhenComponentRemoved(t *testing.T) {
+	r := newTestRegistry()
+	s := newTestSystem(r)
+	r.AddSystem(0, s)
+
+	e := r.CreateEntity()
+	Add(r, e, position{})
+	Add(r, e, velocity{})
+	r.Update()
+	if !s.HasEntity(e) {
+		t.Fatalf("Expected %s to be in the system", e)
+	}
+
+	Remove[velocity](r, e)
+	r.Update()
+	if s.HasEntity(e) || len(s.GetSystemEntities()) != 0 {
+		t.Errorf("Expected %s to leave the system, got %v", e, s.GetSystemEntities())
+	}
+}
+
+func TestSystemAddedAfterEntities(t *testing.T) {
+	r := newTestRegistry()
+	e := r.CreateEntity()
+	Add(r, e, position{})
+	Add(r, e, velocity{})
+	r.Update()
+
+	s := newTestSystem(r)
+	r.AddSystem(0, s)
+	r.Update()
+	if !s.HasEntity(e) {
+		t.Errorf("Expected existing %s to join a system added later", e)
+	}
+}
+
+func TestRequireComponentErrorIsReported(t *testing.T) {
+	r := NewRegistry(1, newTestRegistry().logger)
+	s := newTestSystem(r)
+	if err := r.AddSystem(0, s); err == nil {
+		t.Error("Expected AddSystem to report the component limit error")
+	}
+	if r.HasSystem(0) {
+		t.Error("Expected the system to not be registered")
+	}
+}
+
+func TestRemoveSystemRunsTeardowns(t *testing.T) {
+	r := newTestRegistry()
+	s := newTestSystem(r)
+	r.AddSystem(0, s)
+
+	order := make([]int, 0)
+	s.AddTeardown(func() { order = append(order, 1) })
+	s.AddTeardown(func() { order = append(order, 2) })
+
+	r.RemoveSystem(0)
+	r.RemoveSystem(0)
+	if len(order) != 2 || order[0] != 2 || order[1] != 1 {
+		t.Errorf("Expected teardowns to run once in reverse order, got %v", order)
+	}
+	if r.HasSystem(0) {
+		t.Error("Expected the system to be removed")
+	}
+}
@@ -2,8 +2,10 @@ package ecs
 
 import (
 	"errors"
+	"maps"
 	"reflect"
 	"sync"
+	"sync/atomic"
 )
 
 var (
@@ -16,7 +18,10 @@ type TypeRegistry struct {
 	maxItems int
 	nextID   int
 	typeIDs  map[reflect.Type]int
-	mu       sync.Mutex
+	// ids is a copy of typeIDs for lock free reads. Registering replaces it
+	// with a new copy, so a loaded map is never written to.
+	ids atomic.Pointer[map[reflect.Type]int]
+	mu  sync.Mutex
 }
 
 func NewTypeRegistry(maxItems int) *TypeRegistry {
@@ -28,11 +33,17 @@ func NewTypeRegistry(maxItems int) *TypeRegistry {
 }
 
 func (r *TypeRegistry) Size() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	return len(r.typeIDs)
 }
 
+// GetTypeIDs returns a copy of the registered types, registries sharing r may
+// register new types while the copy is read.
 func (r *TypeRegistry) GetTypeIDs() map[reflect.Type]int {
-	return r.typeIDs
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return maps.Clone(r.typeIDs)
 }
 
 func (r *TypeRegistry) Register(item any) (int, error) {
@@ -41,16 +52,18 @@ func (r *TypeRegistry) Register(item any) (int, error) {
 	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if r.nextID >= r.maxItems {
-		return -1, ErrMaxItemsExceeded
-	}
 	itemType := reflect.TypeOf(item)
 	itemTypeID, exists := r.typeIDs[itemType]
 	if exists {
 		return itemTypeID, nil
 	}
+	if r.nextID >= r.maxItems {
+		return -1, ErrMaxItemsExceeded
+	}
 	id := r.nextID
 	r.typeIDs[itemType] = id
+	ids := maps.Clone(r.typeIDs)
+	r.ids.Store(&ids)
 	r.nextID++
 	return id, nil
 }
@@ -59,11 +72,8 @@ func (r *TypeRegistry) Get(item any) (int, error) {
 	if item == nil {
 		return -1, ErrNilItem
 	}
-	itemType := reflect.TypeOf(item)
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	itemTypeID, exists := r.typeIDs[itemType]
-	if !exists {
+	itemTypeID := r.lookup(reflect.TypeOf(item))
+	if itemTypeID == -1 {
 		return -1, ErrTypeNotFound
 	}
 	return itemTypeID, nil
@@ -73,10 +83,16 @@ func (r *TypeRegistry) Getx(item any) int {
 	if item == nil {
 		return -1
 	}
-	itemType := reflect.TypeOf(item)
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	itemTypeID, exists := r.typeIDs[itemType]
+	return r.lookup(reflect.TypeOf(item))
+}
+
+// lookup returns the id of itemType without taking the lock, or -1.
+func (r *TypeRegistry) lookup(itemType reflect.Type) int {
+	ids := r.ids.Load()
+	if ids == nil {
+		return -1
+	}
+	itemTypeID, exists := (*ids)[itemType]
 	if !exists {
 		return -1
 	}
@@ -1,6 +1,7 @@
 package ecs
 
 import (
+	"reflect"
 	"sync"
 	"testing"
 )
@@ -45,12 +46,27 @@ func TestRegisterExceedingMaxItems(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Unexpected error during first registration: %v", err)
 	}
-	_, err = reg.Register("second")
+	_, err = reg.Register(2)
 	if err != ErrMaxItemsExceeded {
 		t.Errorf("Expected ErrMaxItemsExceeded, got %v", err)
 	}
 }
 
+func TestRegisterExistingItemAtLimit(t *testing.T) {
+	reg := NewTypeRegistry(2)
+	first, _ := reg.Register("first")
+	if _, err := reg.Register(1); err != nil {
+		t.Fatalf("Unexpected error during second registration: %v", err)
+	}
+	id, err := reg.Register("again")
+	if err != nil || id != first {
+		t.Errorf("Expected registered types to keep resolving at the limit, got id=%d err=%v", id, err)
+	}
+	if _, err := reg.Register(1.5); err != ErrMaxItemsExceeded {
+		t.Errorf("Expected ErrMaxItemsExceeded for a new type, got %v", err)
+	}
+}
+
 func TestRegisterSameItem(t *testing.T) {
 	reg := NewTypeRegistry(5)
 	id1, err := reg.Register("item")
@@ -135,3 +151,23 @@ func TestConcurrentGet(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestGetTypeIDsWhileRegistering(t *testing.T) {
+	reg := NewTypeRegistry(64)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 64; i++ {
+			reg.Register(reflect.New(reflect.ArrayOf(i, reflect.TypeFor[int]())).Elem().Interface())
+		}
+	}()
+	for i := 0; i < 64; i++ {
+		for range reg.GetTypeIDs() {
+		}
+	}
+	wg.Wait()
+	if ids := reg.GetTypeIDs(); len(ids) != 64 {
+		t.Errorf("Expected 64 types, got %d", len(ids))
+	}
+}
@@ -0,0 +1,122 @@
+package ecs
+
+import "fmt"
+
+// WithComponentTypes makes the registry share types with other registries, so
+// a component type has the same id in all of them. The registry takes its
+// component limit from types.
+func WithComponentTypes(types *TypeRegistry) RegistryOption {
+	return func(r *Registry) *Registry {
+		r.componentTypes = types
+		r.maxComponentCount = types.maxItems
+		return r
+	}
+}
+
+// ComponentTypes returns the component types of r, pass it to
+// WithComponentTypes to create registries sharing them.
+func (r *Registry) ComponentTypes() *TypeRegistry {
+	return r.componentTypes
+}
+
+// CopyEntity creates a copy of entity in dst with the same components, name,
+// tags and children. Components are copied by value, or with their Clone
+// method when they implement Cloner. src and dst may be the same registry,
+// copying a named entity then fails with ErrNameTaken, see Registry.Clone.
+func CopyEntity(src, dst *Registry, entity Entity) (Entity, error) {
+	if !src.IsAlive(entity) {
+		return Entity{}, ErrEntityNotAlive
+	}
+	copied := dst.CreateEntity()
+	if err := copyEntityInto(src, dst, entity, copied, true); err != nil {
+		dst.KillEntity(copied)
+		return Entity{}, fmt.Errorf("copy %s: %w", entity, err)
+	}
+	return copied, nil
+}
+
+// MoveEntity copies entity and its children to dst and kills them in src. The
+// originals are removed from src by its next Update.
+func MoveEntity(src, dst *Registry, entity Entity) (Entity, error) {
+	name := src.GetName(entity)
+	// the name must be free in dst, and in src when moving within src
+	src.SetName(entity, "")
+	moved, err := CopyEntity(src, dst, entity)
+	if err != nil {
+		src.SetName(entity, name)
+		return Entity{}, err
+	}
+	if name != "" {
+		if err := dst.SetName(moved, name); err != nil {
+			dst.KillEntity(moved)
+			src.SetName(entity, name)
+			return Entity{}, fmt.Errorf("move %s: %w", entity, err)
+		}
+	}
+	src.KillEntity(entity)
+	return moved, nil
+}
+
+// Clone creates a copy of entity like CopyEntity without its name. The clone
+// has the same parent as entity and joins systems on the next Update.
+func (r *Registry) Clone(entity Entity) (Entity, error) {
+	if !r.IsAlive(entity) {
+		return Entity{}, ErrEntityNotAlive
+	}
+	clone := r.CreateEntity()
+	err := copyEntityInto(r, r, entity, clone, false)
+	if parent, ok := GetParent(r, entity); ok && err == nil {
+		err = SetParent(r, clone, parent)
+	}
+	if err != nil {
+		r.KillEntity(clone)
+		return Entity{}, fmt.Errorf("clone %s: %w", entity, err)
+	}
+	return clone, nil
+}
+
+// Clone records cloning entity, see Registry.Clone. entity may be a
+// placeholder of the same buffer. It returns a placeholder for the clone.
+func (c *Commands) Clone(entity Entity) Entity {
+	return c.reserve(func(r *Registry, spawned *placeholders) (Entity, error) {
+		entity, err := resolve(entity, spawned)
+		if err != nil {
+			return Entity{}, err
+		}
+		return r.Clone(entity)
+	})
+}
+
+func copyEntityInto(src, dst *Registry, entity, copied Entity, names bool) error {
+	for componentID, pool := range src.componentPools {
+		if pool == nil || !src.hasComponent(entity, ComponentTypeID(componentID)) {
+			continue
+		}
+		// the hierarchy is rebuilt below, its handles belong to src
+		switch pool.(type) {
+		case *Pool[Parent], *Pool[Children]:
+			continue
+		}
+		if err := pool.copyTo(dst, entity.GetID(), copied); err != nil {
+			return err
+		}
+	}
+	if name := src.GetName(entity); name != "" && names {
+		if err := dst.SetName(copied, name); err != nil {
+			return err
+		}
+	}
+	for _, tag := range src.GetTags(entity) {
+		dst.AddTag(copied, tag)
+	}
+	for _, child := range GetChildren(src, entity) {
+		copiedChild := dst.CreateEntity()
+		if err := SetParent(dst, copiedChild, copied); err != nil {
+			return err
+		}
+		if err := copyEntityInto(src, dst, child, copiedChild, names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package ecs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kubil6y/go_game_engine/pkg/logger"
+)
+
+func TestSharedComponentTypes(t *testing.T) {
+	menu := newTestRegistry()
+	ComponentID[velocity](menu)
+	game := NewRegistry(8, logger.New(logger.WithLogLevel(logger.LEVEL_OFF)), WithComponentTypes(menu.ComponentTypes()))
+
+	gameID, _ := ComponentID[position](game)
+	menuID, _ := ComponentID[position](menu)
+	if gameID != menuID || gameID != 1 {
+		t.Errorf("Expected position to have id 1 in both registries, got %d and %d", gameID, menuID)
+	}
+}
+
+func TestMoveEntity(t *testing.T) {
+	src := newTestRegistry()
+	dst := NewRegistry(src.maxComponentCount, src.logger, WithComponentTypes(src.ComponentTypes()))
+	dst.CreateEntity()
+
+	parent := src.CreateEntity()
+	child := src.CreateEntity()
+	Add(src, parent, position{X: 1})
+	Add(src, child, velocity{X: 2})
+	SetParent(src, child, parent)
+	src.SetName(parent, "player")
+	src.AddTag(parent, "hero")
+	src.Update()
+
+	moved, err := MoveEntity(src, dst, parent)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	src.Update()
+	dst.Update()
+
+	if src.IsAlive(parent) || src.IsAlive(child) {
+		t.Error("Expected the moved entities to be killed in the source registry")
+	}
+	if p, _ := Get[position](dst, moved); p == nil || p.X != 1 {
+		t.Errorf("Expected position to be moved, got %+v", p)
+	}
+	if e, ok := dst.FindByName("player"); !ok || e != moved || !dst.HasTag(moved, "hero") {
+		t.Errorf("Expected name and tags to be moved, got %s (ok=%v)", e, ok)
+	}
+	children := GetChildren(dst, moved)
+	if len(children) != 1 {
+		t.Fatalf("Expected the child to be moved, got %v", children)
+	}
+	if v, _ := Get[velocity](dst, children[0]); v == nil || v.X != 2 {
+		t.Errorf("Expected the child's velocity to be moved, got %+v", v)
+	}
+	if parent, _ := GetParent(dst, children[0]); parent != moved {
+		t.Errorf("Expected the child to point to %s, got %s", moved, parent)
+	}
+}
+
+func TestCopyEntity(t *testing.T) {
+	r := newTestRegistry()
+	e := r.CreateEntity()
+	Add(r, e, position{X: 3})
+
+	copied, err := CopyEntity(r, r, e)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	p, _ := Get[position](r, copied)
+	p.X = 4
+	if original, _ := Get[position](r, e); original.X != 3 {
+		t.Errorf("Expected the copy to not share its component, got %+v", original)
+	}
+
+	r.SetName(e, "unique")
+	if _, err := CopyEntity(r, r, e); !errors.Is(err, ErrNameTaken) {
+		t.Errorf("Expected ErrNameTaken, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build headless
+
+package engine
+
+import (
+	"errors"
+)
+
+var (
+	ErrNoDisplay = errors.New("built without SDL, only headless apps can be initialized")
+)
+
+// Rect is the type of the camera resource, it has the fields of sdl.Rect.
+type Rect struct {
+	X, Y, W, H int32
+}
+
+// Event is never sent to event hooks, builds without SDL poll no events.
+type Event any
+
+type display struct{}
+
+func newDisplay() display {
+	return display{}
+}
+
+func (a *App) initializeDisplay() error {
+	if !a.headless {
+		return ErrNoDisplay
+	}
+	return nil
+}
+
+func (a *App) processInput() {}
+
+func (a *App) beginFrame() {}
+
+func (a *App) endFrame() {}
+
+func (a *App) destroyDisplay() {}
@@ -0,0 +1,131 @@
+//go:build !headless
+
+package engine
+
+import (
+	"github.com/kubil6y/go_game_engine/pkg/asset_store"
+	"github.com/kubil6y/go_game_engine/pkg/ecs"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Rect is the type of the camera resource.
+type Rect = sdl.Rect
+
+// Event is an event polled from SDL.
+type Event = sdl.Event
+
+// display holds the SDL state of an App, window and renderer stay nil in
+// headless mode.
+type display struct {
+	window     *sdl.Window
+	renderer   *sdl.Renderer
+	assetStore *asset_store.AssetStore
+}
+
+func newDisplay() display {
+	return display{assetStore: asset_store.New()}
+}
+
+func (a *App) initializeDisplay() error {
+	if !a.headless {
+		if err := a.initializeWindow(); err != nil {
+			return err
+		}
+		ecs.SetResource(a.registry, a.display.renderer)
+	}
+	ecs.SetResource(a.registry, a.display.assetStore)
+	return nil
+}
+
+func (a *App) initializeWindow() error {
+	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+		a.logger.Error(err, "failed to initialize sdl", nil)
+		return err
+	}
+
+	var windowFlags uint32 = sdl.WINDOW_SHOWN
+	if a.fullscreen {
+		windowFlags = sdl.WINDOW_BORDERLESS
+	}
+	window, err := sdl.CreateWindow(a.title, sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED, a.width, a.height, windowFlags)
+	if err != nil {
+		a.logger.Error(err, "failed to create window", nil)
+		return err
+	}
+	a.display.window = window
+
+	var rendererFlags uint32 = sdl.RENDERER_ACCELERATED
+	if a.vsync {
+		rendererFlags |= sdl.RENDERER_PRESENTVSYNC
+	}
+	renderer, err := sdl.CreateRenderer(window, -1, rendererFlags)
+	if err != nil {
+		a.logger.Error(err, "failed to create renderer", nil)
+		return err
+	}
+	a.display.renderer = renderer
+
+	if a.fullscreen {
+		err = window.SetFullscreen(sdl.WINDOW_FULLSCREEN_DESKTOP)
+		if err != nil {
+			a.logger.Error(err, "failed to set fullscreen", nil)
+			return err
+		}
+	}
+
+	err = renderer.SetLogicalSize(a.width, a.height)
+	if err != nil {
+		a.logger.Error(err, "failed to set logical size", nil)
+		return err
+	}
+	return nil
+}
+
+func (a *App) processInput() {
+	if a.headless {
+		return
+	}
+	for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+		if _, quit := event.(*sdl.QuitEvent); quit {
+			a.logger.Debug("Quitting", nil)
+			a.Quit()
+		}
+		for _, hook := range a.eventHooks {
+			hook(a, event)
+		}
+	}
+}
+
+func (a *App) beginFrame() {
+	if a.headless {
+		return
+	}
+	a.display.renderer.SetDrawColor(0, 0, 0, 0)
+	a.display.renderer.Clear()
+}
+
+func (a *App) endFrame() {
+	if a.headless {
+		return
+	}
+	a.display.renderer.Present()
+}
+
+func (a *App) destroyDisplay() {
+	a.display.assetStore.Clear()
+	if a.headless || !a.initialized {
+		return
+	}
+	a.display.renderer.Destroy()
+	a.display.window.Destroy()
+	sdl.Quit()
+}
+
+func (a *App) AssetStore() *asset_store.AssetStore {
+	return a.display.assetStore
+}
+
+// Renderer returns nil in headless mode.
+func (a *App) Renderer() *sdl.Renderer {
+	return a.display.renderer
+}
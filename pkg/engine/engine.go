@@ -0,0 +1,424 @@
+// Package engine runs games built on the ecs package. An App owns the
+// window, the loop and the shared resources, games plug in their components,
+// systems and levels through hooks.
+//
+// Built with the headless tag the package doesn't use SDL, so it builds
+// without cgo, and only headless Apps can be initialized.
+package engine
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/kubil6y/go_game_engine/pkg/clock"
+	"github.com/kubil6y/go_game_engine/pkg/config"
+	"github.com/kubil6y/go_game_engine/pkg/ecs"
+	"github.com/kubil6y/go_game_engine/pkg/eventbus"
+	"github.com/kubil6y/go_game_engine/pkg/logger"
+	"github.com/kubil6y/go_game_engine/pkg/timestep"
+)
+
+const (
+	DEFAULT_TITLE          = "Game"
+	DEFAULT_WIDTH          = 800
+	DEFAULT_HEIGHT         = 600
+	DEFAULT_FPS            = 60
+	DEFAULT_TICK_RATE      = 60
+	DEFAULT_MAX_STEPS      = timestep.DEFAULT_MAX_STEPS
+	DEFAULT_MAX_COMPONENTS = 32
+	DEFAULT_ASSET_ROOT     = "./assets"
+
+	// GROUP_GRAPHICS holds the systems drawing with the renderer, the App
+	// disables it in headless mode.
+	GROUP_GRAPHICS = "graphics"
+)
+
+var (
+	ErrNotInitialized = errors.New("app is not initialized")
+)
+
+// SetupHook loads a level: it registers components, creates entities and
+// adds systems. Setup hooks run in order before the first frame.
+type SetupHook func(app *App) error
+
+// UpdateHook runs after the update stage of every simulation step.
+type UpdateHook func(app *App, dt float32)
+
+// RenderHook runs after the render stage, before the frame is presented.
+type RenderHook func(app *App)
+
+// EventHook is called with every SDL event polled by the App.
+type EventHook func(app *App, event Event)
+
+type App struct {
+	title         string
+	width         int32
+	height        int32
+	fps           int
+	tickRate      int
+	maxSteps      int
+	maxComponents int
+	fullscreen    bool
+	vsync         bool
+	headless      bool
+	assetRoot     string
+	initialized   bool
+	running       bool
+	paused        bool
+	prevFrame     time.Duration
+	display       display
+	camera        *Rect
+	logger        *logger.Logger
+	registry      *ecs.Registry
+	events        *eventbus.EventBus
+	timestep      *timestep.FixedStep
+	// clock times frames, gameTime only advances with simulation steps and
+	// is the clock systems read
+	clock    clock.Clock
+	gameTime *clock.Manual
+
+	setupHooks  []SetupHook
+	updateHooks []UpdateHook
+	renderHooks []RenderHook
+	eventHooks  []EventHook
+}
+
+type Option func(a *App) *App
+
+func WithTitle(title string) Option {
+	return func(a *App) *App {
+		a.title = title
+		return a
+	}
+}
+
+// WithWindowSize sets the logical size of the window, which is also the size
+// of the camera.
+func WithWindowSize(width, height int32) Option {
+	return func(a *App) *App {
+		a.width = width
+		a.height = height
+		return a
+	}
+}
+
+// WithFullscreen makes the window cover the desktop, true by default.
+func WithFullscreen(fullscreen bool) Option {
+	return func(a *App) *App {
+		a.fullscreen = fullscreen
+		return a
+	}
+}
+
+// WithVSync waits for the display refresh before presenting frames.
+func WithVSync(vsync bool) Option {
+	return func(a *App) *App {
+		a.vsync = vsync
+		return a
+	}
+}
+
+// WithFPS caps how many frames are rendered per second.
+func WithFPS(fps int) Option {
+	return func(a *App) *App {
+		a.fps = max(fps, 1)
+		return a
+	}
+}
+
+// WithTickRate sets how many simulation steps run per second, independent
+// of the frame rate.
+func WithTickRate(tickRate int) Option {
+	return func(a *App) *App {
+		a.tickRate = max(tickRate, 1)
+		return a
+	}
+}
+
+// WithMaxSteps limits how many simulation steps a slow frame runs to catch
+// up, see timestep.WithMaxSteps.
+func WithMaxSteps(maxSteps int) Option {
+	return func(a *App) *App {
+		a.maxSteps = max(maxSteps, 1)
+		return a
+	}
+}
+
+func WithLogger(logger *logger.Logger) Option {
+	return func(a *App) *App {
+		a.logger = logger
+		return a
+	}
+}
+
+// WithAssetRoot sets the directory AssetPath resolves paths against.
+func WithAssetRoot(root string) Option {
+	return func(a *App) *App {
+		a.assetRoot = root
+		return a
+	}
+}
+
+// WithConfig applies the window, frame rate, catch-up and asset settings of
+// c. Its log level and debug flag are left to the caller, which owns the
+// logger and the overlays.
+func WithConfig(c config.Config) Option {
+	return func(a *App) *App {
+		a.title = c.Title
+		a.width = int32(c.Width)
+		a.height = int32(c.Height)
+		a.fullscreen = c.Fullscreen
+		a.vsync = c.VSync
+		a.fps = max(c.FPS, 1)
+		a.maxSteps = max(c.MaxSteps, 1)
+		a.assetRoot = c.AssetRoot
+		return a
+	}
+}
+
+// WithMaxComponents sets how many component types the registry supports.
+func WithMaxComponents(maxComponents int) Option {
+	return func(a *App) *App {
+		a.maxComponents = maxComponents
+		return a
+	}
+}
+
+// WithClock replaces the real clock used to time frames.
+func WithClock(c clock.Clock) Option {
+	return func(a *App) *App {
+		a.clock = c
+		return a
+	}
+}
+
+// WithHeadless runs the App without a window or a renderer. Systems in
+// GROUP_GRAPHICS are disabled and the App is driven by RunHeadless.
+func WithHeadless() Option {
+	return func(a *App) *App {
+		a.headless = true
+		return a
+	}
+}
+
+func New(opts ...Option) *App {
+	a := &App{
+		title:         DEFAULT_TITLE,
+		width:         DEFAULT_WIDTH,
+		height:        DEFAULT_HEIGHT,
+		fps:           DEFAULT_FPS,
+		tickRate:      DEFAULT_TICK_RATE,
+		maxSteps:      DEFAULT_MAX_STEPS,
+		maxComponents: DEFAULT_MAX_COMPONENTS,
+		fullscreen:    true,
+		assetRoot:     DEFAULT_ASSET_ROOT,
+		logger:        logger.New(),
+		display:       newDisplay(),
+		events:        eventbus.NewEventBus(),
+		clock:         clock.NewReal(),
+		gameTime:      clock.NewManual(),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.registry = ecs.NewRegistry(a.maxComponents, a.logger)
+	a.timestep = timestep.New(a.tickRate, timestep.WithMaxSteps(a.maxSteps))
+	a.camera = &Rect{X: 0, Y: 0, W: a.width, H: a.height}
+	return a
+}
+
+// SETUP ////////////////////
+// OnSetup registers hook to run before the first frame.
+func (a *App) OnSetup(hook SetupHook) {
+	a.setupHooks = append(a.setupHooks, hook)
+}
+
+func (a *App) OnUpdate(hook UpdateHook) {
+	a.updateHooks = append(a.updateHooks, hook)
+}
+
+func (a *App) OnRender(hook RenderHook) {
+	a.renderHooks = append(a.renderHooks, hook)
+}
+
+func (a *App) OnEvent(hook EventHook) {
+	a.eventHooks = append(a.eventHooks, hook)
+}
+
+// Initialize opens the window, unless the App is headless, and makes the
+// shared resources available to systems: the renderer and the asset store
+// (only with SDL), the camera (a *Rect), the event bus, Interpolation and
+// the game clock (a clock.Clock).
+func (a *App) Initialize() error {
+	a.logger.Debug("App Initialize called", nil)
+	if err := a.initializeDisplay(); err != nil {
+		return err
+	}
+
+	ecs.SetResource(a.registry, a.camera)
+	ecs.SetResource(a.registry, a.events)
+	ecs.SetResource(a.registry, Interpolation{Alpha: 1})
+	ecs.SetResource[clock.Clock](a.registry, a.gameTime)
+	a.registry.SetGroupEnabled(GROUP_GRAPHICS, !a.headless)
+	a.initialized = true
+	a.running = true
+	return nil
+}
+
+func (a *App) setup() error {
+	if !a.initialized {
+		return ErrNotInitialized
+	}
+	for _, hook := range a.setupHooks {
+		if err := hook(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LOOP ////////////////////
+// Run runs the setup hooks and then the loop until Quit is called or the
+// window is closed.
+func (a *App) Run() error {
+	if err := a.setup(); err != nil {
+		return err
+	}
+	a.prevFrame = a.clock.Now()
+	for a.running {
+		a.processInput()
+		a.update()
+		a.render()
+	}
+	return nil
+}
+
+// RunHeadless runs the setup hooks and then simulation steps back to back,
+// without waiting for real time, until done returns true or maxTicks steps
+// ran. A nil done runs maxTicks steps. It returns the number of steps run.
+func (a *App) RunHeadless(maxTicks int, done func(app *App) bool) (int, error) {
+	if err := a.setup(); err != nil {
+		return 0, err
+	}
+	dt := a.timestep.Dt()
+	ticks := 0
+	for a.running && ticks < maxTicks && (done == nil || !done(a)) {
+		a.step(dt)
+		a.render()
+		ticks++
+	}
+	return ticks, nil
+}
+
+// Quit stops the loop after the current frame.
+func (a *App) Quit() {
+	a.running = false
+}
+
+func (a *App) update() {
+	frameDuration := time.Second / time.Duration(a.fps)
+	waitDuration := frameDuration - (a.clock.Now() - a.prevFrame)
+	if waitDuration > 0 && waitDuration <= frameDuration {
+		a.clock.Sleep(waitDuration)
+	}
+	frameTime := a.clock.Now() - a.prevFrame
+	a.prevFrame = a.clock.Now()
+
+	// Systems always see the same dt, however long the frame took
+	dt := a.timestep.Dt()
+	for steps := a.timestep.Advance(frameTime); steps > 0; steps-- {
+		a.step(dt)
+	}
+}
+
+// step runs one simulation step.
+func (a *App) step(dt float32) {
+	a.registry.Update()
+
+	if err := a.registry.RunStage(ecs.StagePreUpdate, dt); err != nil {
+		a.logger.Error(err, "failed to run pre-update stage", nil)
+	}
+	if err := a.registry.RunStage(ecs.StageUpdate, dt); err != nil {
+		a.logger.Error(err, "failed to run update stage", nil)
+	}
+	for _, hook := range a.updateHooks {
+		hook(a, dt)
+	}
+	if !a.paused {
+		a.gameTime.Advance(a.timestep.Step())
+	}
+}
+
+func (a *App) render() {
+	// Headless steps are never between two states. Only the systems outside
+	// GROUP_GRAPHICS, like cameras, run.
+	alpha := float32(1)
+	if !a.headless {
+		alpha = a.timestep.Alpha()
+	}
+	a.beginFrame()
+	ecs.SetResource(a.registry, Interpolation{Alpha: alpha})
+	if err := a.registry.RunStage(ecs.StageRender, 0); err != nil {
+		a.logger.Error(err, "failed to run render stage", nil)
+	}
+	for _, hook := range a.renderHooks {
+		hook(a)
+	}
+	a.endFrame()
+}
+
+func (a *App) Destroy() {
+	a.destroyDisplay()
+}
+
+// ACCESSORS ////////////////////
+func (a *App) Registry() *ecs.Registry {
+	return a.registry
+}
+
+func (a *App) Logger() *logger.Logger {
+	return a.logger
+}
+
+func (a *App) Events() *eventbus.EventBus {
+	return a.events
+}
+
+func (a *App) Camera() *Rect {
+	return a.camera
+}
+
+// AssetPath returns path relative to the asset root.
+func (a *App) AssetPath(path string) string {
+	return filepath.Join(a.assetRoot, path)
+}
+
+func (a *App) IsHeadless() bool {
+	return a.headless
+}
+
+// GameTime returns the clock advanced by every simulation step.
+func (a *App) GameTime() clock.Clock {
+	return a.gameTime
+}
+
+// SetTimeScale speeds up (scale > 1) or slows down (scale < 1) the
+// simulation, see timestep.FixedStep.SetTimeScale.
+func (a *App) SetTimeScale(scale float64) {
+	a.timestep.SetTimeScale(scale)
+}
+
+func (a *App) TimeScale() float64 {
+	return a.timestep.TimeScale()
+}
+
+// SetPaused stops or restarts the game clock. Steps keep running while paused,
+// it is up to the game to disable the systems that must stop with it.
+func (a *App) SetPaused(paused bool) {
+	a.paused = paused
+}
+
+func (a *App) IsPaused() bool {
+	return a.paused
+}
@@ -0,0 +1,209 @@
+package engine
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kubil6y/go_game_engine/pkg/clock"
+	"github.com/kubil6y/go_game_engine/pkg/ecs"
+	"github.com/kubil6y/go_game_engine/pkg/logger"
+)
+
+type position struct {
+	X float32
+}
+
+type velocity struct {
+	X float32
+}
+
+type movementSystem struct {
+	*ecs.BaseSystem
+}
+
+func (s *movementSystem) Update(dt float32) {
+	for _, c := range ecs.Iter2[position, velocity](s.GetQuery()) {
+		c.A.X += c.B.X * dt
+	}
+}
+
+// newTestApp returns a headless App stepping 4 times per second, so dt is
+// exact in float32.
+func newTestApp(opts ...Option) *App {
+	defaults := []Option{
+		WithHeadless(),
+		WithClock(clock.NewManual()),
+		WithTickRate(4),
+		WithLogger(logger.New(logger.WithLogLevel(logger.LEVEL_OFF))),
+	}
+	return New(append(defaults, opts...)...)
+}
+
+// setupMovement spawns an entity moving 2 units per second.
+func setupMovement(app *App, entity *ecs.Entity) {
+	app.OnSetup(func(app *App) error {
+		r := app.Registry()
+		*entity = r.CreateEntity()
+		ecs.Add(r, *entity, position{})
+		ecs.Add(r, *entity, velocity{X: 2})
+		s := &movementSystem{BaseSystem: ecs.NewBaseSystem("MovementSystem", app.Logger(), r)}
+		ecs.RequireComponent[position](s.BaseSystem)
+		ecs.RequireComponent[velocity](s.BaseSystem)
+		return r.AddSystem(0, s)
+	})
+}
+
+func TestRunHeadless(t *testing.T) {
+	app := newTestApp()
+	var entity ecs.Entity
+	setupMovement(app, &entity)
+	if _, err := app.RunHeadless(8, nil); !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("Expected ErrNotInitialized before Initialize, got %v", err)
+	}
+	if err := app.Initialize(); err != nil {
+		t.Fatalf("Unexpected error initializing: %v", err)
+	}
+	defer app.Destroy()
+
+	ticks, err := app.RunHeadless(8, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error running: %v", err)
+	}
+	if ticks != 8 {
+		t.Errorf("Expected 8 ticks, got %d", ticks)
+	}
+	if p, _ := ecs.Get[position](app.Registry(), entity); p == nil || p.X != 4 {
+		t.Errorf("Expected 8 steps of 0.25s to move the entity to 4, got %+v", p)
+	}
+	if now := app.GameTime().Now(); now != 2*time.Second {
+		t.Errorf("Expected the game clock to advance by the steps run, got %v", now)
+	}
+}
+
+func TestRunHeadlessUntilDone(t *testing.T) {
+	app := newTestApp()
+	var entity ecs.Entity
+	setupMovement(app, &entity)
+	if err := app.Initialize(); err != nil {
+		t.Fatalf("Unexpected error initializing: %v", err)
+	}
+	defer app.Destroy()
+
+	ticks, err := app.RunHeadless(100, func(app *App) bool {
+		p, _ := ecs.Get[position](app.Registry(), entity)
+		return p.X >= 1
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error running: %v", err)
+	}
+	if ticks != 2 {
+		t.Errorf("Expected done to stop the run after 2 ticks, got %d", ticks)
+	}
+}
+
+func TestAppLifecycle(t *testing.T) {
+	app := newTestApp(WithFPS(4))
+	calls := make([]string, 0)
+	renders := 0
+	app.OnSetup(func(app *App) error {
+		calls = append(calls, "setup")
+		return nil
+	})
+	app.OnUpdate(func(app *App, dt float32) {
+		calls = append(calls, "update")
+		if dt != 0.25 {
+			t.Errorf("Expected update hooks to get the step dt, got %v", dt)
+		}
+	})
+	app.OnRender(func(app *App) {
+		calls = append(calls, "render")
+		renders++
+		if renders == 3 {
+			app.Quit()
+		}
+	})
+	if err := app.Initialize(); err != nil {
+		t.Fatalf("Unexpected error initializing: %v", err)
+	}
+	if err := app.Run(); err != nil {
+		t.Fatalf("Unexpected error running: %v", err)
+	}
+	app.Destroy()
+
+	// one frame per step since the frame rate matches the tick rate
+	expected := []string{"setup", "update", "render", "update", "render", "update", "render"}
+	if !slices.Equal(calls, expected) {
+		t.Errorf("Expected hooks %v, got %v", expected, calls)
+	}
+	if now := app.GameTime().Now(); now != 750*time.Millisecond {
+		t.Errorf("Expected 3 steps of game time, got %v", now)
+	}
+}
+
+func TestAppSetupErrorStopsRun(t *testing.T) {
+	app := newTestApp()
+	setupErr := errors.New("no level")
+	app.OnSetup(func(app *App) error { return setupErr })
+	app.OnUpdate(func(app *App, dt float32) {
+		t.Error("Expected no step to run after a failed setup")
+	})
+	if err := app.Initialize(); err != nil {
+		t.Fatalf("Unexpected error initializing: %v", err)
+	}
+	defer app.Destroy()
+	if err := app.Run(); !errors.Is(err, setupErr) {
+		t.Errorf("Expected the setup error, got %v", err)
+	}
+}
+
+func TestPausedAppStopsGameTime(t *testing.T) {
+	app := newTestApp()
+	if err := app.Initialize(); err != nil {
+		t.Fatalf("Unexpected error initializing: %v", err)
+	}
+	defer app.Destroy()
+
+	app.SetPaused(true)
+	if ticks, _ := app.RunHeadless(4, nil); ticks != 4 {
+		t.Errorf("Expected steps to run while paused, got %d", ticks)
+	}
+	if now := app.GameTime().Now(); now != 0 {
+		t.Errorf("Expected the game clock to stop while paused, got %v", now)
+	}
+	app.SetPaused(false)
+	app.RunHeadless(4, nil)
+	if now := app.GameTime().Now(); now != time.Second {
+		t.Errorf("Expected the game clock to restart after unpausing, got %v", now)
+	}
+}
+
+func TestAppMaxSteps(t *testing.T) {
+	frameClock := clock.NewManual()
+	app := newTestApp(WithFPS(4), WithClock(frameClock), WithMaxSteps(2))
+	updates, renders := 0, 0
+	app.OnUpdate(func(app *App, dt float32) {
+		updates++
+		if updates == 1 {
+			// the first frame takes 2s, 8 steps are due after it
+			frameClock.Advance(2 * time.Second)
+		}
+	})
+	app.OnRender(func(app *App) {
+		renders++
+		if renders == 2 {
+			app.Quit()
+		}
+	})
+	if err := app.Initialize(); err != nil {
+		t.Fatalf("Unexpected error initializing: %v", err)
+	}
+	defer app.Destroy()
+	if err := app.Run(); err != nil {
+		t.Fatalf("Unexpected error running: %v", err)
+	}
+	if updates != 3 {
+		t.Errorf("Expected the slow frame to catch up 2 steps only, got %d steps", updates)
+	}
+}
@@ -0,0 +1,13 @@
+package engine
+
+// Interpolation is how far the rendered frame is between the last two
+// simulation steps, renderers draw transforms at their interpolated position.
+type Interpolation struct {
+	Alpha float32
+}
+
+// MapBounds is the size of the loaded tilemap in world coordinates.
+type MapBounds struct {
+	Width  float32
+	Height float32
+}
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Tile is a cell of a tilemap and the tile of the tileset drawn in it.
+type Tile struct {
+	Col    int
+	Row    int
+	SrcCol int
+	SrcRow int
+}
+
+// ReadTilemap reads a map of numRows lines of numCols comma separated tiles.
+// Each tile is two digits, the row and the column of the tileset tile.
+func ReadTilemap(rd io.Reader, numCols, numRows int) ([]Tile, error) {
+	reader := bufio.NewReader(rd)
+	tiles := make([]Tile, 0, numCols*numRows)
+	for y := 0; y < numRows; y++ {
+		for x := 0; x < numCols; x++ {
+			var digits [2]int
+			for i := range digits {
+				ch, err := reader.ReadByte()
+				if err != nil {
+					return nil, fmt.Errorf("tile %d,%d: %w", x, y, err)
+				}
+				digits[i], _ = strconv.Atoi(string(ch))
+			}
+			// separator or end of line
+			reader.Discard(1)
+			tiles = append(tiles, Tile{Col: x, Row: y, SrcRow: digits[0], SrcCol: digits[1]})
+		}
+	}
+	return tiles, nil
+}
+
+func LoadTilemap(path string, numCols, numRows int) ([]Tile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	tiles, err := ReadTilemap(file, numCols, numRows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tiles, nil
+}
@@ -2,73 +2,125 @@ package eventbus
 
 import (
 	"reflect"
+	"slices"
 	"sync"
 )
 
 type EventID int
 type EventCallback func(any)
 
+// Subscription identifies one listener registered with On or Subscribe,
+// registering the same function twice gives two subscriptions.
+type Subscription struct {
+	bus *EventBus
+	id  uint64
+	// set for typed subscriptions
+	eventType reflect.Type
+	eventID   EventID
+}
+
+// Unsubscribe removes the listener of s from its bus, see
+// EventBus.Unsubscribe. It can be passed around as a cleanup func.
+func (s Subscription) Unsubscribe() {
+	if s.bus != nil {
+		s.bus.Unsubscribe(s)
+	}
+}
+
+type listener[F any] struct {
+	id       uint64
+	callback F
+}
+
 type EventBus struct {
-	callbacks map[EventID][]EventCallback
-	mu        sync.RWMutex
+	callbacks map[EventID][]listener[EventCallback]
+	// [key = event type] [value = listeners of func(event T)]
+	handlers map[reflect.Type][]listener[any]
+	lastID   uint64
+	mu       sync.RWMutex
 }
 
 func NewEventBus() *EventBus {
 	return &EventBus{
-		callbacks: make(map[EventID][]EventCallback),
+		callbacks: make(map[EventID][]listener[EventCallback]),
+		handlers:  make(map[reflect.Type][]listener[any]),
 	}
 }
 
-func (b *EventBus) On(eventID EventID, callback EventCallback) bool {
+func (b *EventBus) On(eventID EventID, callback EventCallback) Subscription {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	_, exists := b.callbacks[eventID]
-	if !exists {
-		b.callbacks[eventID] = make([]EventCallback, 0)
-	}
-
-	for _, v := range b.callbacks[eventID] {
-		if reflect.ValueOf(v).Pointer() == reflect.ValueOf(callback).Pointer() {
-			return false
-		}
-	}
-
-	b.callbacks[eventID] = append(b.callbacks[eventID], callback)
-	return true
+	b.lastID++
+	b.callbacks[eventID] = append(b.callbacks[eventID], listener[EventCallback]{id: b.lastID, callback: callback})
+	return Subscription{bus: b, id: b.lastID, eventID: eventID}
 }
 
-func (b *EventBus) Off(eventID EventID, callback EventCallback) bool {
+// Unsubscribe removes exactly the listener of sub and reports whether it was
+// still subscribed.
+func (b *EventBus) Unsubscribe(sub Subscription) bool {
+	if sub.bus != b {
+		return false
+	}
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	callbacks, exists := b.callbacks[eventID]
-	if !exists {
-		return false
+	// Listeners are copied on removal because Emit and Publish iterate
+	// without holding the lock
+	if sub.eventType != nil {
+		handlers, removed := removeListener(b.handlers[sub.eventType], sub.id)
+		b.handlers[sub.eventType] = handlers
+		return removed
 	}
+	callbacks, removed := removeListener(b.callbacks[sub.eventID], sub.id)
+	b.callbacks[sub.eventID] = callbacks
+	return removed
+}
 
-	for i, v := range callbacks {
-		if reflect.ValueOf(v).Pointer() == reflect.ValueOf(callback).Pointer() {
-			b.callbacks[eventID] = append(callbacks[:i], callbacks[i+1:]...)
-			return true
-		}
+func removeListener[F any](listeners []listener[F], id uint64) ([]listener[F], bool) {
+	index := slices.IndexFunc(listeners, func(l listener[F]) bool {
+		return l.id == id
+	})
+	if index < 0 {
+		return listeners, false
 	}
-
-	return false
+	return slices.Delete(slices.Clone(listeners), index, index+1), true
 }
 
 func (b *EventBus) Emit(eventID EventID, payload any) bool {
 	b.mu.RLock()
-	defer b.mu.RUnlock()
+	callbacks := b.callbacks[eventID]
+	b.mu.RUnlock()
 
-	callbacks, exists := b.callbacks[eventID]
-	if !exists {
-		return false
+	for _, l := range callbacks {
+		l.callback(payload)
 	}
 
-	for _, callback := range callbacks {
-		callback(payload)
-	}
+	return len(callbacks) > 0
+}
 
-	return true
+// Subscribe registers handler to be called with every T published on b.
+// Events are routed by their type, so handlers receive the type they expect.
+func Subscribe[T any](b *EventBus, handler func(event T)) Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	eventType := reflect.TypeFor[T]()
+	b.lastID++
+	b.handlers[eventType] = append(b.handlers[eventType], listener[any]{id: b.lastID, callback: handler})
+	return Subscription{bus: b, id: b.lastID, eventType: eventType}
+}
+
+// Publish calls the handlers subscribed to T with event in the order they
+// subscribed, and reports whether there were any. Handlers may subscribe
+// and publish themselves, handlers added during Publish see the next event.
+func Publish[T any](b *EventBus, event T) bool {
+	b.mu.RLock()
+	handlers := b.handlers[reflect.TypeFor[T]()]
+	b.mu.RUnlock()
+
+	for _, l := range handlers {
+		l.callback.(func(event T))(event)
+	}
+	return len(handlers) > 0
 }
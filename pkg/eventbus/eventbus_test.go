@@ -0,0 +1,119 @@
+package eventbus
+
+import (
+	"testing"
+)
+
+type hit struct {
+	damage int
+}
+
+type heal struct {
+	amount int
+}
+
+func TestPublishRoutesByType(t *testing.T) {
+	b := NewEventBus()
+	damage := 0
+	heals := 0
+	Subscribe(b, func(e hit) { damage += e.damage })
+	Subscribe(b, func(e hit) { damage += e.damage * 10 })
+	Subscribe(b, func(e heal) { heals++ })
+
+	if !Publish(b, hit{damage: 2}) {
+		t.Error("Expected Publish to report the hit handlers")
+	}
+	if damage != 22 {
+		t.Errorf("Expected both hit handlers to run, got damage=%d", damage)
+	}
+	if heals != 0 {
+		t.Errorf("Expected heal handlers to ignore hits, got %d calls", heals)
+	}
+	if Publish(b, "unrelated") {
+		t.Error("Expected Publish without handlers to return false")
+	}
+}
+
+func TestSubscribeDuringPublish(t *testing.T) {
+	b := NewEventBus()
+	calls := 0
+	Subscribe(b, func(e hit) {
+		calls++
+		Subscribe(b, func(e hit) { calls++ })
+	})
+
+	Publish(b, hit{})
+	if calls != 1 {
+		t.Errorf("Expected the new handler to wait for the next event, got %d calls", calls)
+	}
+	Publish(b, hit{})
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+}
+
+type counter struct {
+	hits int
+}
+
+func (c *counter) onHit(e hit) {
+	c.hits++
+}
+
+func TestSubscriptionsOfSameMethod(t *testing.T) {
+	b := NewEventBus()
+	first, second := &counter{}, &counter{}
+	Subscribe(b, first.onHit)
+	sub := Subscribe(b, second.onHit)
+
+	Publish(b, hit{})
+	if first.hits != 1 || second.hits != 1 {
+		t.Errorf("Expected both instances to be subscribed, got %d and %d hits", first.hits, second.hits)
+	}
+
+	if !b.Unsubscribe(sub) {
+		t.Error("Expected Unsubscribe to remove the second handler")
+	}
+	if b.Unsubscribe(sub) {
+		t.Error("Expected a second Unsubscribe to report false")
+	}
+	Publish(b, hit{})
+	if first.hits != 2 || second.hits != 1 {
+		t.Errorf("Expected only the second handler to be removed, got %d and %d hits", first.hits, second.hits)
+	}
+}
+
+func TestUnsubscribeEventID(t *testing.T) {
+	b := NewEventBus()
+	calls := make([]int, 0)
+	callback := func(payload any) { calls = append(calls, payload.(int)) }
+	first := b.On(1, callback)
+	b.On(1, callback)
+	other := NewEventBus()
+
+	if other.Unsubscribe(first) {
+		t.Error("Expected a subscription of another bus to be ignored")
+	}
+	first.Unsubscribe()
+	b.Emit(1, 7)
+	if len(calls) != 1 {
+		t.Errorf("Expected exactly one listener left, got %d calls", len(calls))
+	}
+}
+
+func TestUnsubscribeDuringPublish(t *testing.T) {
+	b := NewEventBus()
+	calls := 0
+	var second Subscription
+	Subscribe(b, func(e hit) {
+		calls++
+		second.Unsubscribe()
+	})
+	second = Subscribe(b, func(e hit) { calls++ })
+
+	Publish(b, hit{})
+	Publish(b, hit{})
+	if calls != 3 {
+		t.Errorf("Expected the removed handler to still see the event being published, got %d calls", calls)
+	}
+}
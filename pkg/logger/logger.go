@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"time"
 )
@@ -34,6 +35,23 @@ func (l LogLevel) String() string {
 	}
 }
 
+// ParseLogLevel returns the level named name, case-insensitively.
+func ParseLogLevel(name string) (LogLevel, error) {
+	switch strings.ToUpper(name) {
+	case "DEBUG":
+		return LEVEL_DEBUG, nil
+	case "INFO":
+		return LEVEL_INFO, nil
+	case "ERROR":
+		return LEVEL_ERROR, nil
+	case "FATAL":
+		return LEVEL_FATAL, nil
+	case "OFF":
+		return LEVEL_OFF, nil
+	}
+	return LEVEL_OFF, fmt.Errorf("unknown log level %q, expected debug, info, error, fatal or off", name)
+}
+
 const (
 	Reset  = "\033[0m"
 	Red    = "\033[31m"
@@ -0,0 +1,101 @@
+// Package timestep decouples the simulation rate from the frame rate. Frames
+// feed the real time they took into an accumulator, which is drained in
+// steps of a fixed duration.
+package timestep
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	DEFAULT_MAX_STEPS = 5
+)
+
+type FixedStep struct {
+	step        time.Duration
+	maxSteps    int
+	timeScale   float64
+	accumulator time.Duration
+}
+
+type Option func(f *FixedStep) *FixedStep
+
+// New returns a FixedStep running tickRate steps per second.
+func New(tickRate int, opts ...Option) *FixedStep {
+	f := &FixedStep{
+		step:      time.Second / time.Duration(max(tickRate, 1)),
+		maxSteps:  DEFAULT_MAX_STEPS,
+		timeScale: 1,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// WithMaxSteps limits how many steps a single frame may run at a time scale
+// of 1, faster scales raise the limit in proportion.
+func WithMaxSteps(maxSteps int) Option {
+	return func(f *FixedStep) *FixedStep {
+		f.maxSteps = max(maxSteps, 1)
+		return f
+	}
+}
+
+func WithTimeScale(scale float64) Option {
+	return func(f *FixedStep) *FixedStep {
+		f.SetTimeScale(scale)
+		return f
+	}
+}
+
+// Advance adds the time a frame took and returns how many steps to run.
+// When more than the maximum number of steps are due the remaining time is
+// dropped, so a slow frame slows the game down for a moment instead of making
+// every following frame slower catching up (the spiral of death). The
+// maximum grows with the time scale so fast forward isn't clamped with it.
+func (f *FixedStep) Advance(frameTime time.Duration) int {
+	f.accumulator += time.Duration(float64(frameTime) * f.timeScale)
+	steps := int(f.accumulator / f.step)
+	maxSteps := int(math.Ceil(float64(f.maxSteps) * max(f.timeScale, 1)))
+	if steps > maxSteps {
+		steps = maxSteps
+		f.accumulator %= f.step
+	} else {
+		f.accumulator -= time.Duration(steps) * f.step
+	}
+	return steps
+}
+
+// Step returns the simulated duration of a step.
+func (f *FixedStep) Step() time.Duration {
+	return f.step
+}
+
+// Dt returns the duration of a step in seconds, the dt passed to systems.
+func (f *FixedStep) Dt() float32 {
+	return float32(f.step.Seconds())
+}
+
+// Alpha returns how far the current frame is between the last step and the
+// next one, from 0 to 1. Renderers interpolate between the previous and the
+// current state with it.
+func (f *FixedStep) Alpha() float32 {
+	return float32(f.accumulator) / float32(f.step)
+}
+
+// SetTimeScale speeds up (scale > 1) or slows down (scale < 1) the
+// simulation. A scale of 0 freezes it.
+func (f *FixedStep) SetTimeScale(scale float64) {
+	f.timeScale = max(scale, 0)
+}
+
+func (f *FixedStep) TimeScale() float64 {
+	return f.timeScale
+}
+
+// Reset drops the accumulated time, for example after loading a level.
+func (f *FixedStep) Reset() {
+	f.accumulator = 0
+}
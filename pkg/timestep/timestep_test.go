@@ -0,0 +1,74 @@
+package timestep
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdvance(t *testing.T) {
+	f := New(50)
+	if f.Step() != 20*time.Millisecond || f.Dt() != 0.02 {
+		t.Errorf("Expected 20ms steps, got %v (dt=%v)", f.Step(), f.Dt())
+	}
+
+	frames := []struct {
+		frameTime time.Duration
+		steps     int
+		alpha     float32
+	}{
+		{10 * time.Millisecond, 0, 0.5},
+		{15 * time.Millisecond, 1, 0.25},
+		{40 * time.Millisecond, 2, 0.25},
+	}
+	for i, frame := range frames {
+		if steps := f.Advance(frame.frameTime); steps != frame.steps {
+			t.Errorf("frame %d: expected %d steps, got %d", i, frame.steps, steps)
+		}
+		if alpha := f.Alpha(); alpha != frame.alpha {
+			t.Errorf("frame %d: expected alpha %v, got %v", i, frame.alpha, alpha)
+		}
+	}
+}
+
+func TestAdvanceClampsCatchUp(t *testing.T) {
+	f := New(100, WithMaxSteps(3))
+	if steps := f.Advance(time.Second + 5*time.Millisecond); steps != 3 {
+		t.Errorf("Expected catch-up to be clamped to 3 steps, got %d", steps)
+	}
+	if alpha := f.Alpha(); alpha != 0.5 {
+		t.Errorf("Expected the excess time to be dropped, got alpha %v", alpha)
+	}
+	if steps := f.Advance(10 * time.Millisecond); steps != 1 {
+		t.Errorf("Expected the next frame to run normally, got %d steps", steps)
+	}
+}
+
+func TestTimeScale(t *testing.T) {
+	f := New(100, WithTimeScale(0.5))
+	if steps := f.Advance(40 * time.Millisecond); steps != 2 {
+		t.Errorf("Expected slow motion to run 2 steps, got %d", steps)
+	}
+	f.SetTimeScale(2)
+	if steps := f.Advance(20 * time.Millisecond); steps != 4 {
+		t.Errorf("Expected fast forward to run 4 steps, got %d", steps)
+	}
+	f.SetTimeScale(-1)
+	if steps := f.Advance(time.Second); steps != 0 || f.TimeScale() != 0 {
+		t.Errorf("Expected negative scales to freeze time, got %d steps", steps)
+	}
+}
+
+func TestTimeScaleRaisesMaxSteps(t *testing.T) {
+	f := New(60, WithTimeScale(8))
+	total := 0
+	for range 60 {
+		total += f.Advance(time.Second / 60)
+	}
+	// a second of frames runs 8 seconds of steps, give or take rounding
+	if total < 479 || total > 480 {
+		t.Errorf("Expected 8x fast forward to keep up, got %d steps in a second", total)
+	}
+	if steps := f.Advance(time.Second); steps != DEFAULT_MAX_STEPS*8 {
+		t.Errorf("Expected catch-up to be clamped to %d steps, got %d", DEFAULT_MAX_STEPS*8, steps)
+	}
+}
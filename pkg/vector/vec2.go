@@ -88,3 +88,8 @@ func Sub(v, u Vec2) Vec2 {
 func Mul(v Vec2, r float32) Vec2 {
 	return Vec2{v.X * r, v.Y * r}
 }
+
+// Lerp returns the point at t between v (t = 0) and u (t = 1).
+func Lerp(v, u Vec2, t float32) Vec2 {
+	return Vec2{v.X + (u.X-v.X)*t, v.Y + (u.Y-v.Y)*t}
+}